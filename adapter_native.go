@@ -3,49 +3,32 @@
 package crontask
 
 import (
-	"fmt"
+	"bytes"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/goccy/go-yaml"
 )
 
 // Adaptador para entornos nativos (no-WASM).
 type nativeAdapter struct {
-	ctab   *crontab
-	logger *log.Logger
-}
+	ctab *crontab
 
-// Inicializador específico para entornos no-WASM
-func newCronAdapter() cronAdapter {
-	// Abrir o crear archivo log.txt para escritura y append
-	logFile, err := os.OpenFile("log.txt", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		// Si hay error al abrir el archivo, usar solo stdout
-		return &nativeAdapter{
-			ctab:   newCrontab(),
-			logger: log.New(os.Stdout, "CRONTASK: ", log.Ldate|log.Ltime),
-		}
-	}
-
-	// Crear un MultiWriter para escribir en stdout y en el archivo
-	multiWriter := io.MultiWriter(os.Stdout, logFile)
-
-	// Crear logger con formato y destino configurados
-	logger := log.New(multiWriter, "CRONTASK: ", log.Ldate|log.Ltime|log.Lshortfile)
-
-	return &nativeAdapter{
-		ctab:   newCrontab(),
-		logger: logger,
-	}
+	procsMu sync.Mutex
+	procs   map[int]struct{} // pids of the commands in progress, each one leads its process group
 }
 
-func (a *nativeAdapter) Log(args ...any) {
-	// Usar el logger configurado en lugar de log.Println directamente
-	a.logger.Println(args...)
+// Inicializador específico para entornos no-WASM
+func newCronAdapter(logger *slog.Logger) cronAdapter {
+	ctab := newCrontab()
+	ctab.setLogger(logger)
+	return &nativeAdapter{ctab: ctab}
 }
 
 func (a *nativeAdapter) AddProgramTask(schedule string, fn any, args ...any) error {
@@ -56,10 +39,47 @@ func (a *nativeAdapter) AddProgramTask(schedule string, fn any, args ...any) err
 	return a.ctab.AddJob(schedule, jobFunc, args...)
 }
 
+func (a *nativeAdapter) ScheduleJob(id, schedule string, fn func()) error {
+	return a.ctab.AddJobWithID(id, schedule, fn)
+}
+
+func (a *nativeAdapter) UnscheduleJob(id string) {
+	a.ctab.RemoveJob(id)
+}
+
 func (a *nativeAdapter) RunAllAdapterTasks() {
 	a.ctab.RunAll()
 }
 
+func (a *nativeAdapter) stopScheduler() {
+	if a.ctab != nil {
+		a.ctab.Stop()
+	}
+}
+
+func (a *nativeAdapter) signalRunning(kill bool) {
+	a.procsMu.Lock()
+	defer a.procsMu.Unlock()
+	for pid := range a.procs {
+		signalProcess(pid, kill)
+	}
+}
+
+// track registers a started command for signalRunning until the returned func is called
+func (a *nativeAdapter) track(pid int) (untrack func()) {
+	a.procsMu.Lock()
+	defer a.procsMu.Unlock()
+	if a.procs == nil {
+		a.procs = make(map[int]struct{})
+	}
+	a.procs[pid] = struct{}{}
+	return func() {
+		a.procsMu.Lock()
+		delete(a.procs, pid)
+		a.procsMu.Unlock()
+	}
+}
+
 func (a *nativeAdapter) GetBasePath() string {
 	// Get the current working directory as the base path
 	dir, err := os.Getwd()
@@ -70,75 +90,181 @@ func (a *nativeAdapter) GetBasePath() string {
 	return dir
 }
 
-func (a *nativeAdapter) GetTasksFromPath(tasksPath string) ([]Tasks, error) {
-	// Read file contents
-	data, err := os.ReadFile(tasksPath)
+func (a *nativeAdapter) GetTasksFromPath(tasksPath, format string) (taskFile, error) {
+	return loadTaskFiles(tasksPath, format, a)
+}
+
+func (a *nativeAdapter) checkTask(t Task) error {
+	return checkNativeTask(t)
+}
+
+func (a *nativeAdapter) expandTasksPath(pattern string) ([]string, error) {
+	if info, err := os.Stat(pattern); err == nil {
+		if !info.IsDir() {
+			return []string{pattern}, nil
+		}
+		if filepath.Base(pattern) == "cron.d" {
+			return cronDirFiles(pattern)
+		}
+		// conf.d style directory, every tasks file in name order
+		var paths []string
+		for _, ext := range taskFileExtensions {
+			matches, _ := filepath.Glob(filepath.Join(pattern, "*"+ext))
+			paths = append(paths, matches...)
+		}
+		sort.Strings(paths)
+		return paths, nil
+	}
+
+	paths, err := filepath.Glob(pattern)
 	if err != nil {
 		return nil, err
 	}
+	if len(paths) == 0 && !strings.ContainsAny(pattern, "*?[") {
+		// Not a pattern, report the missing file itself
+		_, err := os.Stat(pattern)
+		return nil, err
+	}
+	return paths, nil
+}
 
-	// Parse YAML data using go-yaml
-	var tasks []Task
-	err = yaml.Unmarshal(data, &tasks)
+// cronDirFiles lists the files of a /etc/cron.d directory skipping the ones
+// cron ignores too: hidden files and editor or package manager leftovers
+func cronDirFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		// Try to parse with wrapper (tasks: [...])
-		var wrapper struct {
-			Tasks []Task `yaml:"tasks"`
-		}
-		err2 := yaml.Unmarshal(data, &wrapper)
-		if err2 != nil || len(wrapper.Tasks) == 0 {
-			return nil, err // return original error
-		}
-		tasks = wrapper.Tasks
-	}
-
-	return []Tasks{tasks}, nil
-}
-
-func (a *nativeAdapter) ExecuteCmd(cmd Task) error {
-	// Split args string to proper arguments array
-	args := []string{}
-	if cmd.Args != "" {
-		// Simple parsing - this could be improved with proper argument parsing
-		inQuote := false
-		current := ""
-		for _, c := range cmd.Args {
-			if c == '"' || c == '\'' {
-				inQuote = !inQuote
-				continue
-			}
-			if c == ' ' && !inQuote {
-				if current != "" {
-					args = append(args, current)
-					current = ""
-				}
-				continue
-			}
-			current += string(c)
-		}
-		if current != "" {
-			args = append(args, current)
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") || strings.Contains(name, ".dpkg-") {
+			continue
 		}
+		paths = append(paths, filepath.Join(dir, name))
 	}
+	return paths, nil
+}
 
-	// Expand environment variables in command and args
-	command := os.ExpandEnv(cmd.Command)
-	expandedArgs := make([]string, len(args))
-	for i, arg := range args {
-		expandedArgs[i] = os.ExpandEnv(arg)
+func (a *nativeAdapter) resolveInclude(from, include string) string {
+	if filepath.IsAbs(include) {
+		return include
 	}
+	return filepath.Join(filepath.Dir(from), include)
+}
 
-	// Use exec.Command directly
-	execCmd := exec.Command(command, expandedArgs...)
-
-	// Capture command output for better debugging
-	output, err := execCmd.CombinedOutput()
+func (a *nativeAdapter) readTaskFile(path, format string) (taskFile, error) {
+	// Read file contents
+	data, err := os.ReadFile(path)
 	if err != nil {
-		a.Log("Command execution failed:", err, "Output:", string(output))
-		return err
+		return taskFile{}, err
 	}
+	return parseTaskData(data, path, format)
+}
 
-	currentTime := time.Now().Format("2006-01-02 15:04:05")
-	fmt.Printf("*- [%s] %v completed.\n%s\n", currentTime, cmd.Name, string(output))
+// checkNativeTask validates the fields only the native executor understands
+func checkNativeTask(t Task) error {
+	if err := checkCredential(t); err != nil {
+		return err
+	}
+	if err := checkLimits(t); err != nil {
+		return err
+	}
+	if t.Stdin != "" && t.StdinFile != "" {
+		return newErr("task", t.Name, "stdin and stdin_file can't be used together")
+	}
 	return nil
 }
+
+func (a *nativeAdapter) ExecuteCmd(cmd Task) (RunResult, error) {
+	args := splitArgs(cmd.Args)
+
+	// Environment variables were interpolated when the tasks file was loaded
+	execCmd := exec.Command(cmd.Command, args...)
+	execCmd.Dir = cmd.WorkDir
+	setProcessGroup(execCmd)
+
+	if len(cmd.Env) > 0 || len(cmd.env) > 0 {
+		execCmd.Env = append(os.Environ(), cmd.environ()...)
+	}
+
+	result := RunResult{Task: cmd.Name, Start: time.Now()}
+	limits, err := cmd.resourceLimits()
+	if err == nil {
+		err = setCredential(execCmd, cmd)
+	}
+	if err != nil {
+		return a.notStarted(cmd, result, err)
+	}
+
+	// Capture stdout and stderr separately for the outcome checks,
+	// and combined for better debugging
+	var stdout, stderr, output bytes.Buffer
+	execCmd.Stdout = io.MultiWriter(&stdout, &output)
+	execCmd.Stderr = io.MultiWriter(&stderr, &output)
+
+	// Feed standard input from the task, the child sees EOF when it's consumed
+	switch {
+	case cmd.Stdin != "":
+		execCmd.Stdin = strings.NewReader(cmd.Stdin)
+	case cmd.StdinFile != "":
+		stdinFile, err := os.Open(cmd.StdinFile)
+		if err != nil {
+			return a.notStarted(cmd, result, err)
+		}
+		defer stdinFile.Close()
+		execCmd.Stdin = stdinFile
+	}
+
+	err = startWithLimits(execCmd, limits)
+	if err == nil {
+		defer a.track(execCmd.Process.Pid)()
+	}
+	var timedOut atomic.Bool
+	if err == nil && cmd.Timeout != "" {
+		timeout, _ := time.ParseDuration(cmd.Timeout) // checked when the tasks were loaded
+		timer := time.AfterFunc(timeout, func() {
+			timedOut.Store(true)
+			signalProcess(execCmd.Process.Pid, true)
+		})
+		defer timer.Stop()
+	}
+	if err == nil {
+		err = execCmd.Wait()
+	}
+	result.Duration = time.Since(result.Start)
+	result.Output = maskSecrets(output.String(), cmd.secrets)
+
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return a.notStarted(cmd, result, err)
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+
+	result.Status, result.Reason = cmd.outcome(result.ExitCode, stdout.String(), stderr.String())
+	result.Reason = maskSecrets(result.Reason, cmd.secrets)
+	if reason := limitExceeded(execCmd.ProcessState, limits); reason != "" {
+		result.Status, result.Reason = StatusFailed, reason
+	}
+	if timedOut.Load() {
+		result.Status, result.Reason, result.TimedOut = StatusFailed, "timed out after "+cmd.Timeout, true
+	}
+	if result.Status == StatusFailed {
+		return result, newErr(cmd.Name, "failed:", result.Reason)
+	}
+	return result, nil
+}
+
+// notStarted records a run whose command could not be started at all
+func (a *nativeAdapter) notStarted(cmd Task, result RunResult, err error) (RunResult, error) {
+	result.Status, result.ExitCode, result.Reason = StatusFailed, -1, err.Error()
+	result.Duration = time.Since(result.Start)
+	if len(cmd.secrets) > 0 {
+		// The error may quote a command or path holding a secret
+		result.Reason = maskSecrets(result.Reason, cmd.secrets)
+		err = newErr(result.Reason)
+	}
+	return result, err
+}
@@ -3,12 +3,14 @@
 package crontask
 
 import (
+	"log/slog"
 	"strings"
 	"syscall/js"
+	"time"
 )
 
-// Inicializador específico para WASM
-func newCronAdapter() cronAdapter {
+// Inicializador específico para WASM, el registro lo lleva el engine
+func newCronAdapter(logger *slog.Logger) cronAdapter {
 	return &wasmAdapter{}
 }
 
@@ -30,9 +32,12 @@ func (a *wasmAdapter) AddProgramTask(schedule string, fn any, args ...any) error
 	return nil
 }
 
-func (a *wasmAdapter) Log(args ...any) {
-	// Log to the JavaScript console
-	js.Global().Call("console", args...)
+func (a *wasmAdapter) ScheduleJob(id, schedule string, fn func()) error {
+	return a.AddProgramTask(schedule, fn)
+}
+
+func (a *wasmAdapter) UnscheduleJob(id string) {
+	// Jobs started with setTimeout can't be removed
 }
 
 func (a *wasmAdapter) RunAllAdapterTasks() {
@@ -54,7 +59,7 @@ func (a *wasmAdapter) GetBasePath() string {
 	return origin + pathname
 }
 
-func (a *wasmAdapter) GetTasksFromPath(tasksPath string) ([]Tasks, error) {
+func (a *wasmAdapter) GetTasksFromPath(tasksPath, format string) (taskFile, error) {
 
 	// If path doesn't start with http or https, assume it's relative to current path
 	// or if it begins with "/" assume it's relative to domain root
@@ -78,29 +83,46 @@ func (a *wasmAdapter) GetTasksFromPath(tasksPath string) ([]Tasks, error) {
 		}
 	}
 
+	return loadTaskFiles(tasksPath, format, a)
+}
+
+func (a *wasmAdapter) expandTasksPath(pattern string) ([]string, error) {
+	// There are no directory listings over HTTP, every path is a single file
+	return []string{pattern}, nil
+}
+
+func (a *wasmAdapter) resolveInclude(from, include string) string {
+	switch {
+	case strings.HasPrefix(include, "http://") || strings.HasPrefix(include, "https://"):
+		return include
+	case strings.HasPrefix(include, "/"):
+		return js.Global().Get("window").Get("location").Get("origin").String() + include
+	default:
+		return from[:strings.LastIndex(from, "/")+1] + include
+	}
+}
+
+func (a *wasmAdapter) checkTask(t Task) error {
+	return nil
+}
+
+func (a *wasmAdapter) readTaskFile(url, format string) (taskFile, error) {
 	// Use XMLHttpRequest for synchronous requests (since we need to return the result)
 	xhr := js.Global().Get("XMLHttpRequest").New()
-	xhr.Call("open", "GET", tasksPath, false)
+	xhr.Call("open", "GET", url, false)
 	xhr.Call("send")
 	status := xhr.Get("status").Int()
 	if status != 200 {
-		return nil, newErr("failed to fetch YAML config: HTTP ", status)
+		return taskFile{}, newErr("failed to fetch tasks file: HTTP ", status)
 	}
 
-	yamlText := xhr.Get("responseText").String()
-
-	// Convert string to []byte for parsing
-	parser := ymlParser{}
-	tasks, err := parser.ParseYAML([]byte(yamlText))
-	if err != nil {
-		return nil, err
-	}
-
-	// Wrap in Tasks slice to maintain compatibility
-	return []Tasks{tasks}, nil
+	return parseTaskData([]byte(xhr.Get("responseText").String()), url, format)
 }
 
-func (a *wasmAdapter) ExecuteCmd(cmd Task) error {
+func (a *wasmAdapter) ExecuteCmd(cmd Task) (RunResult, error) {
+	// JavaScript functions have no exit code, a call that returns is a success
+	result := RunResult{Task: cmd.Name, Status: StatusSuccess, Start: time.Now()}
 	js.Global().Call(cmd.Command, cmd.Args)
-	return nil
+	result.Duration = time.Since(result.Start)
+	return result, nil
 }
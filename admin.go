@@ -0,0 +1,383 @@
+package crontask
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// heartbeatTimeout is how long the scheduler can go without firing its
+// heartbeat job, every minute, before /health reports it as down
+const heartbeatTimeout = 2*time.Minute + 30*time.Second
+
+// adminTask is a task in the admin API, with its secrets masked
+type adminTask struct {
+	Name        string     `json:"name"`
+	Schedule    string     `json:"schedule,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Command     string     `json:"command"`
+	Args        string     `json:"args,omitempty"`
+	WorkDir     string     `json:"workdir,omitempty"`
+	Timeout     string     `json:"timeout,omitempty"`
+	OnSuccess   []string   `json:"on_success,omitempty"`
+	OnFailure   []string   `json:"on_failure,omitempty"`
+	OnComplete  []string   `json:"on_complete,omitempty"`
+	Paused      bool       `json:"paused"`
+	Running     int        `json:"running"`
+	NextRun     *time.Time `json:"next_run,omitempty"`
+	LastRun     *adminRun  `json:"last_run,omitempty"`
+}
+
+// adminWorkflow is a workflow in the admin API
+type adminWorkflow struct {
+	Name        string      `json:"name"`
+	Schedule    string      `json:"schedule,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Steps       []adminStep `json:"steps"`
+	Paused      bool        `json:"paused"`
+	NextRun     *time.Time  `json:"next_run,omitempty"`
+}
+
+type adminStep struct {
+	Task      string   `json:"task"`
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// adminRun is a finished run in the admin API
+type adminRun struct {
+	Task     string    `json:"task"`
+	RunID    int64     `json:"run_id,omitempty"`
+	Workflow string    `json:"workflow,omitempty"`
+	Status   RunStatus `json:"status"`
+	ExitCode int       `json:"exit_code"`
+	Output   string    `json:"output"`
+	Reason   string    `json:"reason,omitempty"`
+	TimedOut bool      `json:"timed_out,omitempty"`
+	Start    time.Time `json:"start"`
+	Duration float64   `json:"duration_seconds"`
+}
+
+// adminRunning is a run in progress in the admin API
+type adminRunning struct {
+	Task     string    `json:"task"`
+	RunID    int64     `json:"run_id"`
+	Workflow string    `json:"workflow,omitempty"`
+	Start    time.Time `json:"start"`
+	Elapsed  float64   `json:"elapsed_seconds"`
+}
+
+// adminHealth is the /health response
+type adminHealth struct {
+	Status        string     `json:"status"` // ok, stopping or down
+	Scheduled     bool       `json:"scheduled"`
+	LastHeartbeat *time.Time `json:"last_heartbeat,omitempty"`
+	Running       int        `json:"running"`
+	Uptime        float64    `json:"uptime_seconds"`
+}
+
+func newAdminRun(r RunResult) *adminRun {
+	return &adminRun{Task: r.Task, RunID: r.RunID, Workflow: r.Workflow, Status: r.Status, ExitCode: r.ExitCode, Output: r.Output,
+		Reason: r.Reason, TimedOut: r.TimedOut, Start: r.Start, Duration: r.Duration.Seconds()}
+}
+
+// AdminHandler returns the admin API and its web dashboard, the ones served
+// at Config.AdminAddr, to mount in another server. Every /api endpoint
+// requires the header "Authorization: Bearer <Config.AdminToken>", without
+// a token they are all refused.
+//
+//	GET  /                              web dashboard, it asks for the token
+//	GET  /health                        scheduler liveness, 503 when down or stopping
+//	GET  /api/tasks                     tasks and workflows with their next and last run
+//	GET  /api/status                    runs in progress, paused tasks and counts
+//	GET  /api/history?task=x&limit=20   last runs, oldest first
+//	POST /api/tasks/{name}/run          run a task now, ?wait=true waits for its result
+//	POST /api/tasks/{name}/pause        skip its scheduled runs
+//	POST /api/tasks/{name}/resume
+//	POST /api/workflows/{name}/run
+//	POST /api/workflows/{name}/pause
+//	POST /api/workflows/{name}/resume
+//	POST /api/reload                    read the tasks file again
+//	GET  /api/events                    Subscribe as server-sent events
+func (c *CronTaskEngine) AdminHandler() http.Handler {
+	api := http.NewServeMux()
+	api.HandleFunc("GET /api/tasks", c.adminTasks)
+	api.HandleFunc("GET /api/status", c.adminStatus)
+	api.HandleFunc("GET /api/history", c.adminHistory)
+	api.HandleFunc("POST /api/tasks/{name}/run", c.adminRunTask)
+	api.HandleFunc("POST /api/tasks/{name}/pause", c.adminAction("task", c.Pause, "paused"))
+	api.HandleFunc("POST /api/tasks/{name}/resume", c.adminAction("task", c.Resume, "resumed"))
+	api.HandleFunc("POST /api/workflows/{name}/run", c.adminRunWorkflow)
+	api.HandleFunc("POST /api/workflows/{name}/pause", c.adminAction("workflow", c.PauseWorkflow, "paused"))
+	api.HandleFunc("POST /api/workflows/{name}/resume", c.adminAction("workflow", c.ResumeWorkflow, "resumed"))
+	api.HandleFunc("POST /api/reload", c.adminReload)
+	api.HandleFunc("GET /api/events", c.adminEvents)
+
+	index, files := dashboardHandler()
+	mux := http.NewServeMux()
+	mux.Handle("GET /{$}", index)
+	mux.Handle("GET /ui/", files)
+	mux.HandleFunc("GET /health", c.adminHealth)
+	mux.Handle("/api/", c.requireToken(api))
+	return mux
+}
+
+// requireToken refuses the requests without the bearer token of the engine
+func (c *CronTaskEngine) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if c.adminToken == "" || !ok || subtle.ConstantTimeCompare([]byte(token), []byte(c.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="crontask"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *CronTaskEngine) adminTasks(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	running := make(map[string]int)
+	for _, run := range c.Running() {
+		running[run.Task]++
+	}
+	last := make(map[string]RunResult)
+	for _, result := range c.History() {
+		last[result.Task] = result
+	}
+
+	out := struct {
+		Tasks     []adminTask     `json:"tasks"`
+		Workflows []adminWorkflow `json:"workflows"`
+	}{Tasks: []adminTask{}, Workflows: []adminWorkflow{}}
+	for _, t := range c.GetTasks() {
+		task := adminTask{Name: t.Name, Schedule: t.Schedule, Description: scheduleDescription(t.Schedule),
+			Command: maskSecrets(t.Command, t.secrets), Args: maskSecrets(t.Args, t.secrets), WorkDir: maskSecrets(t.WorkDir, t.secrets),
+			Timeout: t.Timeout, OnSuccess: t.OnSuccess, OnFailure: t.OnFailure, OnComplete: t.OnComplete,
+			Paused: c.isPaused("task:" + t.Name), Running: running[t.Name], NextRun: nextRunAt(t.Schedule, now)}
+		if result, ok := last[t.Name]; ok {
+			task.LastRun = newAdminRun(result)
+		}
+		out.Tasks = append(out.Tasks, task)
+	}
+	for _, wf := range c.GetWorkflows() {
+		workflow := adminWorkflow{Name: wf.Name, Schedule: wf.Schedule, Description: scheduleDescription(wf.Schedule),
+			Steps: []adminStep{}, Paused: c.isPaused("workflow:" + wf.Name), NextRun: nextRunAt(wf.Schedule, now)}
+		for _, step := range wf.Steps {
+			workflow.Steps = append(workflow.Steps, adminStep{Task: step.Task, DependsOn: step.DependsOn})
+		}
+		out.Workflows = append(out.Workflows, workflow)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (c *CronTaskEngine) adminStatus(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	c.mu.RLock()
+	scheduled, tasks, workflows := c.scheduled, len(c.tasks), len(c.workflows)
+	c.mu.RUnlock()
+	c.runMu.Lock()
+	stopping := c.stopping
+	c.runMu.Unlock()
+
+	running := []adminRunning{}
+	for _, run := range c.Running() {
+		running = append(running, adminRunning{Task: run.Task, RunID: run.RunID, Workflow: run.Workflow, Start: run.Start, Elapsed: now.Sub(run.Start).Seconds()})
+	}
+	pausedTasks, pausedWorkflows := c.Paused()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"started":          c.started,
+		"uptime_seconds":   now.Sub(c.started).Seconds(),
+		"scheduled":        scheduled,
+		"stopping":         stopping,
+		"tasks":            tasks,
+		"workflows":        workflows,
+		"running":          running,
+		"paused_tasks":     append([]string{}, pausedTasks...),
+		"paused_workflows": append([]string{}, pausedWorkflows...),
+	})
+}
+
+func (c *CronTaskEngine) adminHistory(w http.ResponseWriter, r *http.Request) {
+	task := r.URL.Query().Get("task")
+	limit := historySize
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "invalid limit "+strconv.Quote(s))
+			return
+		}
+		limit = n
+	}
+
+	runs := []*adminRun{}
+	for _, result := range c.History() {
+		if task == "" || result.Task == task {
+			runs = append(runs, newAdminRun(result))
+		}
+	}
+	if len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (c *CronTaskEngine) adminRunTask(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, ok := c.findTask(name); !ok {
+		writeError(w, http.StatusNotFound, "task not found: "+name)
+		return
+	}
+	c.logger.Info("task triggered from the admin API", "task", name, "remote", r.RemoteAddr)
+	if wait, _ := strconv.ParseBool(r.URL.Query().Get("wait")); wait {
+		result, _ := c.RunTask(name)
+		writeJSON(w, http.StatusOK, newAdminRun(result))
+		return
+	}
+	go c.RunTask(name)
+	writeJSON(w, http.StatusAccepted, map[string]string{"task": name, "status": "started"})
+}
+
+func (c *CronTaskEngine) adminRunWorkflow(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, ok := c.findWorkflow(name); !ok {
+		writeError(w, http.StatusNotFound, "workflow not found: "+name)
+		return
+	}
+	c.logger.Info("workflow triggered from the admin API", "workflow", name, "remote", r.RemoteAddr)
+	if wait, _ := strconv.ParseBool(r.URL.Query().Get("wait")); wait {
+		if err := c.RunWorkflow(name); err != nil {
+			writeJSON(w, http.StatusOK, map[string]string{"workflow": name, "status": string(StatusFailed), "reason": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"workflow": name, "status": string(StatusSuccess)})
+		return
+	}
+	go c.RunWorkflow(name)
+	writeJSON(w, http.StatusAccepted, map[string]string{"workflow": name, "status": "started"})
+}
+
+// adminAction calls a pause or resume method with the task or workflow
+// name of the path
+func (c *CronTaskEngine) adminAction(kind string, action func(name string) error, done string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		found := false
+		if kind == "task" {
+			_, found = c.findTask(name)
+		} else {
+			_, found = c.findWorkflow(name)
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, kind+" not found: "+name)
+			return
+		}
+		if err := action(name); err != nil {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{kind: name, "status": done})
+	}
+}
+
+func (c *CronTaskEngine) adminReload(w http.ResponseWriter, r *http.Request) {
+	c.logger.Info("reload requested from the admin API", "remote", r.RemoteAddr)
+	if err := c.Reload(); err != nil {
+		// The rejected file may quote a secret of the running tasks
+		writeError(w, http.StatusUnprocessableEntity, maskSecrets(err.Error(), c.currentSecrets()))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "reloaded"})
+}
+
+func (c *CronTaskEngine) adminHealth(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	c.mu.RLock()
+	scheduled := c.scheduled
+	c.mu.RUnlock()
+	c.runMu.Lock()
+	stopping := c.stopping
+	c.runMu.Unlock()
+
+	health := adminHealth{Status: "ok", Scheduled: scheduled, Running: len(c.Running()), Uptime: now.Sub(c.started).Seconds()}
+	if beat := c.heartbeat.Load(); beat != 0 {
+		last := time.Unix(0, beat)
+		health.LastHeartbeat = &last
+		if now.Sub(last) > heartbeatTimeout {
+			health.Status = "down"
+		}
+	}
+	if stopping {
+		health.Status = "stopping"
+	}
+
+	status := http.StatusOK
+	if health.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, health)
+}
+
+// startAdmin serves AdminHandler at addr and schedules the heartbeat job
+// checked by /health. Shutdown stops the server.
+func (c *CronTaskEngine) startAdmin(addr string) error {
+	if c.adminToken == "" {
+		return newErr("admin API not started: Config.AdminToken is required")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return newErr("admin API not started:", err)
+	}
+
+	c.heartbeat.Store(time.Now().UnixNano())
+	if err := c.adapter.ScheduleJob("engine:heartbeat", "* * * * *", func() {
+		c.heartbeat.Store(time.Now().UnixNano())
+	}); err != nil {
+		ln.Close()
+		return newErr("admin API not started:", err)
+	}
+
+	c.admin = &http.Server{Handler: c.AdminHandler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := c.admin.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			c.logger.Error("admin API stopped", "addr", ln.Addr().String(), "error", err)
+		}
+	}()
+	c.logger.Info("admin API listening", "addr", ln.Addr().String())
+	return nil
+}
+
+// stopAdmin stops the admin server, if it was started
+func (c *CronTaskEngine) stopAdmin(ctx context.Context) {
+	if c.admin == nil {
+		return
+	}
+	c.adapter.UnscheduleJob("engine:heartbeat")
+	c.admin.Shutdown(ctx)
+}
+
+// nextRunAt is the next run of a schedule, nil without one
+func nextRunAt(schedule string, from time.Time) *time.Time {
+	runs, err := NextRuns(schedule, from, 1)
+	if err != nil || len(runs) == 0 {
+		return nil
+	}
+	return &runs[0]
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
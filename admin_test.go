@@ -0,0 +1,154 @@
+package crontask
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// adminRequest calls the admin API of c with the bearer token, when not empty
+func adminRequest(t *testing.T, c *CronTaskEngine, method, target, token string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	c.AdminHandler().ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func TestAdminToken(t *testing.T) {
+	c := newFakeEngine(&fakeAdapter{}, Task{Name: "report", Command: "echo"})
+
+	// Without a configured token nothing but /health is served
+	if code, _ := adminRequest(t, c, "GET", "/api/tasks", ""); code != http.StatusUnauthorized {
+		t.Errorf("no token configured: %d", code)
+	}
+	if err := c.startAdmin("127.0.0.1:0"); err == nil {
+		t.Error("admin API started without a token")
+	}
+
+	c.adminToken = "s3cret"
+	for _, token := range []string{"", "wrong", "s3cret2"} {
+		if code, _ := adminRequest(t, c, "GET", "/api/tasks", token); code != http.StatusUnauthorized {
+			t.Errorf("token %q: %d", token, code)
+		}
+	}
+	if code, body := adminRequest(t, c, "GET", "/api/tasks", "s3cret"); code != http.StatusOK {
+		t.Errorf("valid token: %d %s", code, body)
+	}
+	if code, _ := adminRequest(t, c, "GET", "/health", ""); code != http.StatusOK {
+		t.Errorf("health without token: %d", code)
+	}
+}
+
+func TestAdminTasksMaskSecrets(t *testing.T) {
+	a := &fakeAdapter{outputs: map[string]string{"report": "sent with tok-123"}}
+	c := newFakeEngine(a, Task{Name: "report", Schedule: "0 7 * * *", Command: "curl", Args: "-H 'Authorization: tok-123'", secrets: []string{"tok-123"}})
+	c.adminToken = "s3cret"
+	c.RunTask("report")
+
+	code, body := adminRequest(t, c, "GET", "/api/tasks", "s3cret")
+	if code != http.StatusOK {
+		t.Fatalf("%d %s", code, body)
+	}
+	if strings.Contains(body, "tok-123") {
+		t.Errorf("secret exposed:\n%s", body)
+	}
+	var out struct {
+		Tasks []adminTask `json:"tasks"`
+	}
+	if err := json.Unmarshal([]byte(body), &out); err != nil {
+		t.Fatal(err)
+	}
+	task := out.Tasks[0]
+	if task.Args != "-H 'Authorization: ******'" || task.NextRun == nil || task.LastRun == nil || task.LastRun.Output != "sent with ******" {
+		t.Errorf("task %+v", task)
+	}
+}
+
+func TestAdminRunPauseAndHistory(t *testing.T) {
+	a := &fakeAdapter{exitCodes: map[string]int{"bad": 2}}
+	c := newFakeEngine(a, Task{Name: "report", Schedule: "0 7 * * *"}, Task{Name: "bad"})
+	c.adminToken = "s3cret"
+
+	code, body := adminRequest(t, c, "POST", "/api/tasks/bad/run?wait=true", "s3cret")
+	if code != http.StatusOK || !strings.Contains(body, `"status": "failed"`) || !strings.Contains(body, `"exit_code": 2`) {
+		t.Errorf("run bad: %d %s", code, body)
+	}
+	if code, _ := adminRequest(t, c, "POST", "/api/tasks/missing/run", "s3cret"); code != http.StatusNotFound {
+		t.Errorf("run missing: %d", code)
+	}
+	if code, _ := adminRequest(t, c, "GET", "/api/tasks/bad/run", "s3cret"); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET run: %d", code)
+	}
+
+	if code, body := adminRequest(t, c, "POST", "/api/tasks/report/pause", "s3cret"); code != http.StatusOK {
+		t.Errorf("pause: %d %s", code, body)
+	}
+	c.runScheduled(Task{Name: "report"})
+	if code, _ := adminRequest(t, c, "POST", "/api/tasks/missing/pause", "s3cret"); code != http.StatusNotFound {
+		t.Errorf("pause missing: %d", code)
+	}
+	if _, body := adminRequest(t, c, "GET", "/api/status", "s3cret"); !strings.Contains(body, `"paused_tasks": [
+    "report"
+  ]`) {
+		t.Errorf("status: %s", body)
+	}
+	if code, _ := adminRequest(t, c, "POST", "/api/tasks/report/resume", "s3cret"); code != http.StatusOK {
+		t.Errorf("resume: %d", code)
+	}
+	if code, _ := adminRequest(t, c, "POST", "/api/tasks/report/resume", "s3cret"); code != http.StatusConflict {
+		t.Errorf("resume twice: %d", code)
+	}
+	c.runScheduled(Task{Name: "report"})
+
+	code, body = adminRequest(t, c, "GET", "/api/history?task=report&limit=5", "s3cret")
+	var runs []adminRun
+	if err := json.Unmarshal([]byte(body), &runs); err != nil || code != http.StatusOK {
+		t.Fatalf("history: %d %s %v", code, body, err)
+	}
+	if len(runs) != 2 || runs[0].Status != StatusSkipped || runs[0].Reason != "paused" || runs[1].Status != StatusSuccess {
+		t.Errorf("history %+v", runs)
+	}
+	if code, _ := adminRequest(t, c, "GET", "/api/history?limit=x", "s3cret"); code != http.StatusBadRequest {
+		t.Errorf("bad limit: %d", code)
+	}
+	if names := a.names(); len(names) != 2 {
+		t.Errorf("executed %v, the paused run must not execute", names)
+	}
+}
+
+func TestAdminReloadAndHealth(t *testing.T) {
+	a := &fakeAdapter{fileErr: errors.New("bad yaml near tok-123")}
+	c := newFakeEngine(a, Task{Name: "report", secrets: []string{"tok-123"}})
+	c.setSecrets([]string{"tok-123"})
+	c.adminToken = "s3cret"
+	c.started = time.Now()
+
+	code, body := adminRequest(t, c, "POST", "/api/reload", "s3cret")
+	if code != http.StatusUnprocessableEntity || strings.Contains(body, "tok-123") {
+		t.Errorf("rejected reload: %d %s", code, body)
+	}
+
+	c.heartbeat.Store(time.Now().Add(-time.Minute).UnixNano())
+	if code, body := adminRequest(t, c, "GET", "/health", ""); code != http.StatusOK || !strings.Contains(body, `"status": "ok"`) {
+		t.Errorf("health: %d %s", code, body)
+	}
+	c.heartbeat.Store(time.Now().Add(-5 * time.Minute).UnixNano())
+	if code, body := adminRequest(t, c, "GET", "/health", ""); code != http.StatusServiceUnavailable || !strings.Contains(body, `"status": "down"`) {
+		t.Errorf("stale heartbeat: %d %s", code, body)
+	}
+	c.heartbeat.Store(time.Now().UnixNano())
+	c.runMu.Lock()
+	c.stopping = true
+	c.runMu.Unlock()
+	if code, body := adminRequest(t, c, "GET", "/health", ""); code != http.StatusServiceUnavailable || !strings.Contains(body, `"status": "stopping"`) {
+		t.Errorf("stopping: %d %s", code, body)
+	}
+}
@@ -0,0 +1,46 @@
+package crontask
+
+import "strings"
+
+// splitArgs splits the args: field of a task into arguments. Quotes group
+// words and the other quote character is literal inside them eg: -c 'echo "hi"'.
+// Inside double quotes \" and \\ are a literal quote and backslash; any other
+// backslash is kept as is, so Windows paths need no escaping.
+func splitArgs(s string) []string {
+	args := []string{}
+	var quote rune
+	var current strings.Builder
+	escaped := false
+	for _, c := range s {
+		switch {
+		case escaped:
+			if c != '"' && c != '\\' {
+				current.WriteRune('\\')
+			}
+			current.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote == '"':
+			escaped = true
+		case (c == '"' || c == '\'') && (quote == 0 || quote == c):
+			if quote == 0 {
+				quote = c
+			} else {
+				quote = 0
+			}
+		case c == ' ' && quote == 0:
+			if current.Len() > 0 {
+				args = append(args, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(c)
+		}
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	if current.Len() > 0 {
+		args = append(args, current.String())
+	}
+	return args
+}
@@ -0,0 +1,50 @@
+package crontask
+
+import (
+	"slices"
+	"strconv"
+)
+
+// followUps returns the names of the tasks to run after a run with the given status
+func (t Task) followUps(status RunStatus) []string {
+	var names []string
+	switch status {
+	case StatusSuccess:
+		names = append(names, t.OnSuccess...)
+	case StatusFailed:
+		names = append(names, t.OnFailure...)
+	}
+	return append(names, t.OnComplete...)
+}
+
+// chainEnv describes the triggering run to a follow-up task
+func chainEnv(prev RunResult) []string {
+	return []string{
+		"CRONTASK_PREV_TASK=" + prev.Task,
+		"CRONTASK_PREV_STATUS=" + string(prev.Status),
+		"CRONTASK_PREV_EXIT=" + strconv.Itoa(prev.ExitCode),
+		"CRONTASK_PREV_DURATION=" + strconv.FormatFloat(prev.Duration.Seconds(), 'f', 3, 64),
+	}
+}
+
+// runFollowUps runs the on_success, on_failure and on_complete tasks of a finished run
+// one after the other. chain holds the tasks already run in this chain so a cycle
+// like "a on_failure b, b on_failure a" stops instead of looping forever.
+func (c *CronTaskEngine) runFollowUps(task Task, result RunResult, chain []string) {
+	for _, name := range task.followUps(result.Status) {
+		if slices.Contains(chain, name) {
+			c.logger.Warn("follow-up already ran in this chain, skipping", "task", task.Name, "follow_up", name)
+			continue
+		}
+
+		next, ok := c.findTask(name)
+		if !ok {
+			c.logger.Warn("follow-up task not found", "task", task.Name, "follow_up", name)
+			continue
+		}
+
+		c.logger.Info("running follow-up", "task", name, "after", task.Name, "status", string(result.Status))
+		next.env = append(next.env, chainEnv(result)...)
+		c.runChained(next, chain)
+	}
+}
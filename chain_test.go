@@ -0,0 +1,164 @@
+package crontask
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAdapter records executed tasks and returns the exit code configured per task name
+type fakeAdapter struct {
+	mu        sync.Mutex
+	exitCodes map[string]int
+	outputs   map[string]string // output of each task
+	executed  []Task
+	file      taskFile          // returned by GetTasksFromPath
+	fileErr   error             // returned by GetTasksFromPath
+	jobs      map[string]string // scheduled job id => schedule
+	twice     []string          // job ids scheduled while already scheduled
+	release   chan struct{}     // when set, ExecuteCmd waits for it to be closed
+	programs  []any             // functions of AddProgramTask
+}
+
+func (a *fakeAdapter) AddProgramTask(schedule string, fn any, args ...any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.programs = append(a.programs, fn)
+	return nil
+}
+func (a *fakeAdapter) GetTasksFromPath(tasksPath, format string) (taskFile, error) {
+	if a.fileErr != nil {
+		return taskFile{}, a.fileErr
+	}
+	return a.file, validateTaskFile(a.file, nil).err()
+}
+
+func (a *fakeAdapter) ScheduleJob(id, schedule string, fn func()) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.jobs == nil {
+		a.jobs = map[string]string{}
+	}
+	if _, ok := a.jobs[id]; ok {
+		a.twice = append(a.twice, id)
+	}
+	a.jobs[id] = schedule
+	return nil
+}
+
+func (a *fakeAdapter) UnscheduleJob(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.jobs, id)
+}
+
+func (a *fakeAdapter) GetBasePath() string { return "" }
+func (a *fakeAdapter) RunAllAdapterTasks() {}
+
+func (a *fakeAdapter) ExecuteCmd(cmd Task) (RunResult, error) {
+	a.mu.Lock()
+	a.executed = append(a.executed, cmd)
+	a.mu.Unlock()
+	if a.release != nil {
+		<-a.release
+	}
+
+	result := RunResult{Task: cmd.Name, ExitCode: a.exitCodes[cmd.Name], Output: a.outputs[cmd.Name]}
+	result.Status, result.Reason = cmd.outcome(result.ExitCode, "", "")
+	if result.Status == StatusFailed {
+		return result, newErr(cmd.Name, "failed:", result.Reason)
+	}
+	return result, nil
+}
+
+func (a *fakeAdapter) names() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var names []string
+	for _, t := range a.executed {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func newFakeEngine(a *fakeAdapter, tasks ...Task) *CronTaskEngine {
+	return &CronTaskEngine{adapter: a, tasks: tasks, logger: discardLogger}
+}
+
+func TestTaskChaining(t *testing.T) {
+	a := &fakeAdapter{exitCodes: map[string]int{"upload_dump": 1}}
+	c := newFakeEngine(a,
+		Task{Name: "dump_database", Command: "dump", OnSuccess: []string{"upload_dump"}, OnFailure: []string{"notify"}},
+		Task{Name: "upload_dump", Command: "upload", OnSuccess: []string{"prune_dumps"}, OnFailure: []string{"notify"}, OnComplete: []string{"cleanup"}},
+		Task{Name: "prune_dumps", Command: "prune"},
+		Task{Name: "notify", Command: "notify"},
+		Task{Name: "cleanup", Command: "cleanup"},
+	)
+
+	if err := validateTaskFile(taskFile{Tasks: c.tasks}, nil).err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.ExecuteTask("dump_database"); err != nil {
+		t.Fatalf("triggering task should succeed: %v", err)
+	}
+
+	want := []string{"dump_database", "upload_dump", "notify", "cleanup"}
+	if got := a.names(); !slices.Equal(got, want) {
+		t.Fatalf("executed %v, want %v", got, want)
+	}
+
+	// notify receives the result of upload_dump
+	notify := a.executed[2]
+	for _, env := range []string{"CRONTASK_PREV_TASK=upload_dump", "CRONTASK_PREV_STATUS=failed", "CRONTASK_PREV_EXIT=1"} {
+		if !slices.Contains(notify.env, env) {
+			t.Errorf("follow-up env %v is missing %s", notify.env, env)
+		}
+	}
+
+	if len(c.History()) != 4 {
+		t.Errorf("expected every chained run in the history, got %d", len(c.History()))
+	}
+}
+
+func TestTaskChainingCycle(t *testing.T) {
+	a := &fakeAdapter{exitCodes: map[string]int{"a": 1, "b": 1}}
+	c := newFakeEngine(a,
+		Task{Name: "a", OnFailure: []string{"b"}},
+		Task{Name: "b", OnFailure: []string{"a"}},
+	)
+
+	c.ExecuteTask("a")
+
+	if got := a.names(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("cycle should stop after each task ran once, executed %v", got)
+	}
+}
+
+func TestCheckFollowUpsUnknownTask(t *testing.T) {
+	err := validateTaskFile(taskFile{Tasks: []Task{{Name: "a", Command: "echo", OnComplete: []string{"missing"}}}}, nil).err()
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected unknown follow-up error, got %v", err)
+	}
+}
+
+func TestRunTaskReturnsResult(t *testing.T) {
+	a := &fakeAdapter{exitCodes: map[string]int{"sync": 3}}
+	c := newFakeEngine(a, Task{Name: "sync", Command: "sync", SkipCodes: []int{3}, OnComplete: []string{"report"}}, Task{Name: "report", Command: "report"})
+
+	result, err := c.RunTask("sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Task != "sync" || result.Status != StatusSkipped || result.ExitCode != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	if !slices.Equal(a.names(), []string{"sync", "report"}) {
+		t.Errorf("executed %v, follow-ups must run too", a.names())
+	}
+
+	if _, err := c.RunTask("missing"); err == nil || !strings.Contains(err.Error(), "task not found") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+//go:build !wasm
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cdvelop/crontask"
+)
+
+// convert writes a crontasks.yml from a classic crontab file
+//
+//	crontask convert [-system] [-o crontasks.yml] /etc/crontab
+func convert(args []string) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	system := fs.Bool("system", false, "crontab has a user field after the schedule (/etc/crontab, /etc/cron.d), default: detected from the path")
+	output := fs.String("o", "crontasks.yml", "output file, - for stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: crontask convert [-system] [-o crontasks.yml] <crontab file>")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	input := positional[0]
+	data, err := os.ReadFile(input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	withUser := *system || crontask.IsSystemCrontab(input)
+	yml, err := crontask.ConvertCrontab(data, withUser)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, input+":")
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *output == "-" {
+		os.Stdout.Write(yml)
+		return 0
+	}
+	if err := os.WriteFile(*output, yml, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println("Tasks written to", *output)
+	return 0
+}
@@ -0,0 +1,85 @@
+//go:build !wasm
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cdvelop/crontask"
+)
+
+// execResult is the exec --json output
+type execResult struct {
+	Task     string    `json:"task"`
+	Status   string    `json:"status"`
+	ExitCode int       `json:"exit_code"`
+	Output   string    `json:"output"`
+	Reason   string    `json:"reason,omitempty"`
+	Start    time.Time `json:"start"`
+	Duration float64   `json:"duration_seconds"`
+}
+
+// execTask runs one task now, its follow-up tasks included, and returns
+// the exit code of its command
+//
+//	crontask exec [--config crontasks.yml] [--format yaml] [--json] <task name>
+func execTask(args []string) int {
+	fs := flag.NewFlagSet("exec", flag.ContinueOnError)
+	config := fs.String("config", "crontasks.yml", "tasks file, directory or glob")
+	format := fs.String("format", "", "format of the tasks files: yaml, json, toml or crontab, default: from the file extension")
+	asJSON := fs.Bool("json", false, "print the run result as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: crontask exec [--config crontasks.yml] [--format yaml] [--json] <task name>")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	tasks, workflows, err := crontask.LoadTasks(*config, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	// The log goes to stderr, the standard output is for the result
+	logger, _ := newLogger(os.Stderr, "info", false)
+	engine := crontask.NewCronTaskEngine(crontask.Config{TasksPath: *config, Format: *format, Tasks: tasks, Workflows: workflows, NoAutoSchedule: true, Logger: logger})
+	result, err := engine.RunTask(positional[0])
+	if result.Task == "" {
+		// The task doesn't exist
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *asJSON {
+		printJSON(execResult{
+			Task:     result.Task,
+			Status:   string(result.Status),
+			ExitCode: result.ExitCode,
+			Output:   result.Output,
+			Reason:   result.Reason,
+			Start:    result.Start,
+			Duration: result.Duration.Seconds(),
+		})
+	} else {
+		fmt.Print(result.Output)
+	}
+	return exitCode(result)
+}
+
+// exitCode is the code of the command, or 1 when the run failed without
+// one eg: it couldn't start or fail_on_stderr
+func exitCode(result crontask.RunResult) int {
+	if result.Status == crontask.StatusFailed && result.ExitCode <= 0 {
+		return 1
+	}
+	return result.ExitCode
+}
@@ -0,0 +1,51 @@
+//go:build !wasm
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cdvelop/crontask"
+)
+
+// explain prints what a schedule means
+//
+//	crontask explain [--lang en] [--json] "<schedule>"
+func explain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	lang := fs.String("lang", "en", "language of the description: en or es")
+	asJSON := fs.Bool("json", false, "print JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: crontask explain [--lang en] [--json] "<schedule>"`)
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		// The shell splits an unquoted schedule and expands its * as file names
+		fmt.Fprintln(os.Stderr, "the schedule must be one quoted argument eg: crontask explain \"*/5 * * * *\"")
+		fs.Usage()
+		return 2
+	}
+	schedule := positional[0]
+
+	description, err := crontask.Describe(schedule, *lang)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *asJSON {
+		printJSON(struct {
+			Schedule    string `json:"schedule"`
+			Description string `json:"description"`
+		}{schedule, description})
+		return 0
+	}
+	fmt.Fprintln(os.Stdout, description)
+	return 0
+}
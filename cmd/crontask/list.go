@@ -0,0 +1,130 @@
+//go:build !wasm
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cdvelop/crontask"
+)
+
+// listedTask is a task of the list --json output
+type listedTask struct {
+	Name        string     `json:"name"`
+	Schedule    string     `json:"schedule"`
+	Description string     `json:"description,omitempty"`
+	NextRun     *time.Time `json:"next_run"` // null when the task only runs on demand
+	Command     string     `json:"command"`
+	Args        string     `json:"args,omitempty"`
+}
+
+// listedWorkflow is a workflow of the list --json output
+type listedWorkflow struct {
+	Name        string     `json:"name"`
+	Schedule    string     `json:"schedule"`
+	Description string     `json:"description,omitempty"`
+	NextRun     *time.Time `json:"next_run"`
+	Steps       []string   `json:"steps"`
+}
+
+// list prints the tasks and workflows of a tasks file with their next run
+//
+//	crontask list [--config crontasks.yml] [--format yaml] [--lang en] [--json]
+func list(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	config := fs.String("config", "crontasks.yml", "tasks file, directory or glob")
+	format := fs.String("format", "", "format of the tasks files: yaml, json, toml or crontab, default: from the file extension")
+	lang := fs.String("lang", "en", "language of the schedule descriptions: en or es")
+	asJSON := fs.Bool("json", false, "print JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: crontask list [--config crontasks.yml] [--format yaml] [--lang en] [--json]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	tasks, workflows, err := crontask.LoadTasks(*config, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	now := time.Now()
+	listed := struct {
+		Tasks     []listedTask     `json:"tasks"`
+		Workflows []listedWorkflow `json:"workflows"`
+	}{Tasks: []listedTask{}, Workflows: []listedWorkflow{}}
+	for _, t := range tasks {
+		listed.Tasks = append(listed.Tasks, listedTask{Name: t.Name, Schedule: t.Schedule, Description: describe(t.Schedule, *lang),
+			NextRun: nextRun(t.Schedule, now), Command: t.Command, Args: t.Args})
+	}
+	for _, wf := range workflows {
+		steps := make([]string, len(wf.Steps))
+		for i, step := range wf.Steps {
+			steps[i] = step.Task
+		}
+		listed.Workflows = append(listed.Workflows, listedWorkflow{Name: wf.Name, Schedule: wf.Schedule, Description: describe(wf.Schedule, *lang),
+			NextRun: nextRun(wf.Schedule, now), Steps: steps})
+	}
+
+	if *asJSON {
+		printJSON(listed)
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSCHEDULE\tDESCRIPTION\tNEXT RUN\tCOMMAND")
+	for _, t := range listed.Tasks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.Name, orDash(t.Schedule), orDash(t.Description), formatRun(t.NextRun), t.Command+" "+t.Args)
+	}
+	for _, wf := range listed.Workflows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\tworkflow: %v\n", wf.Name, orDash(wf.Schedule), orDash(wf.Description), formatRun(wf.NextRun), wf.Steps)
+	}
+	w.Flush()
+	return 0
+}
+
+// nextRun returns the next run of a schedule, nil for an on demand one
+func nextRun(schedule string, from time.Time) *time.Time {
+	if schedule == "" {
+		return nil
+	}
+	runs, err := crontask.NextRuns(schedule, from, 1)
+	if err != nil || len(runs) == 0 {
+		return nil
+	}
+	return &runs[0]
+}
+
+// describe returns the description of a schedule, empty for an on demand one
+func describe(schedule, lang string) string {
+	if schedule == "" {
+		return ""
+	}
+	description, _ := crontask.Describe(schedule, lang)
+	return description
+}
+
+func formatRun(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
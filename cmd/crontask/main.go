@@ -3,23 +3,99 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
-	"time"
+	"io"
+	"log/slog"
+	"os"
 
 	"github.com/cdvelop/crontask"
 )
 
+const usage = `Usage: crontask <command> [flags]
+
+Commands:
+  run       start the scheduler daemon (default)
+  list      list the tasks with their schedule and next run
+  exec      run one task now and exit with its exit code
+  validate  check a tasks file
+  next      print the next runs of a schedule
+  explain   describe a schedule in words
+  simulate  print the runs a tasks file would start in a time window
+  convert   write a crontasks.yml from a classic crontab file
+  schema    write the JSON Schema of the tasks file
+
+Run crontask <command> -h for the flags of a command.
+`
+
 func main() {
-	// Create a crontask engine with minimal configuration
-	// It will automatically:
-	// - Load tasks from "crontasks.yml"
-	// - Schedule all tasks
-	// - Log operations
-	crontask.NewCronTaskEngine()
-
-	fmt.Printf("Cron server started %s\n", time.Now().Format("2006-01-02 15:04:05"))
-	fmt.Println("Press Ctrl+C to stop")
-
-	// Keep the program running
-	select {}
+	// The tasks with resource limits start through this same binary
+	crontask.RunLimitsWrapper()
+
+	args := os.Args[1:]
+	if len(args) == 0 || (args[0] != "" && args[0][0] == '-') {
+		// Without a command the binary is the daemon, as before the subcommands
+		os.Exit(run(args))
+	}
+
+	commands := map[string]func([]string) int{
+		"run":      run,
+		"list":     list,
+		"exec":     execTask,
+		"validate": validate,
+		"next":     next,
+		"explain":  explain,
+		"simulate": simulate,
+		"convert":  convert,
+		"schema":   schema,
+	}
+	command, ok := commands[args[0]]
+	if args[0] == "help" {
+		fmt.Print(usage)
+		return
+	}
+	if !ok {
+		fmt.Fprintln(os.Stderr, "unknown command", args[0])
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	os.Exit(command(args[1:]))
+}
+
+// parseFlags parses flags written before or after the positional arguments
+// eg: crontask next "*/5 * * * *" -n 3
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// newLogger returns the logger of the engine writing to w, as JSON or text,
+// from level ("debug", "info", "warn" or "error") up
+func newLogger(w io.Writer, level string, asJSON bool) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: debug, info, warn or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	if asJSON {
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return slog.New(slog.NewTextHandler(w, opts)), nil
+}
+
+// printJSON writes v indented to the standard output
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
 }
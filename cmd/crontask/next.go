@@ -0,0 +1,63 @@
+//go:build !wasm
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cdvelop/crontask"
+)
+
+// next prints the next runs of a schedule
+//
+//	crontask next [-n 10] [--from 2025-01-01T00:00:00Z] [--json] "<schedule>"
+func next(args []string) int {
+	fs := flag.NewFlagSet("next", flag.ContinueOnError)
+	n := fs.Int("n", 10, "number of runs")
+	from := fs.String("from", "", "RFC 3339 start time, default: now")
+	asJSON := fs.Bool("json", false, "print JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: crontask next [-n 10] [--from 2025-01-01T00:00:00Z] [--json] "<schedule>"`)
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		// The shell splits an unquoted schedule and expands its * as file names
+		fmt.Fprintln(os.Stderr, "the schedule must be one quoted argument eg: crontask next \"*/5 * * * *\"")
+		fs.Usage()
+		return 2
+	}
+	schedule := positional[0]
+
+	start := time.Now()
+	if *from != "" {
+		if start, err = time.Parse(time.RFC3339, *from); err != nil {
+			fmt.Fprintln(os.Stderr, "invalid --from:", err)
+			return 2
+		}
+	}
+
+	runs, err := crontask.NextRuns(schedule, start, *n)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *asJSON {
+		printJSON(struct {
+			Schedule string      `json:"schedule"`
+			Runs     []time.Time `json:"runs"`
+		}{schedule, append([]time.Time{}, runs...)})
+		return 0
+	}
+	for _, t := range runs {
+		fmt.Fprintln(os.Stdout, t.Format("2006-01-02 15:04 Mon"))
+	}
+	return 0
+}
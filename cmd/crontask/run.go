@@ -0,0 +1,125 @@
+//go:build !wasm
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+
+	"github.com/cdvelop/crontask"
+)
+
+// run starts the scheduler and keeps the program running until SIGTERM or
+// SIGINT, then sends SIGTERM to the running tasks and waits for them, killing
+// them at the shutdown timeout. SIGHUP reloads the tasks file and SIGUSR1
+// writes the status to the log. The admin API token is read from the
+// CRONTASK_ADMIN_TOKEN environment variable.
+//
+//	crontask run [--config crontasks.yml] [--format yaml] [--watch] [--shutdown-timeout 30s]
+//	             [--log-file crontask.log] [--log-level info] [--log-json] [--metrics-addr :9090]
+//	             [--admin-addr 127.0.0.1:8080]
+func run(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	config := fs.String("config", "crontasks.yml", "tasks file, directory or glob")
+	format := fs.String("format", "", "format of the tasks files: yaml, json, toml or crontab, default: from the file extension")
+	watch := fs.Bool("watch", false, "reload the tasks file when it changes on disk")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 30*time.Second, "how long to wait for the running tasks on SIGTERM or SIGINT before killing them")
+	logFile := fs.String("log-file", "", "also append the log to this file")
+	logLevel := fs.String("log-level", "info", "minimum level of the log: debug, info, warn or error")
+	logJSON := fs.Bool("log-json", false, "write the log as JSON lines")
+	metricsAddr := fs.String("metrics-addr", "", "serve the Prometheus metrics at http://<addr>/metrics eg: :9090")
+	adminAddr := fs.String("admin-addr", "", "serve the admin HTTP API at this address eg: 127.0.0.1:8080, token in CRONTASK_ADMIN_TOKEN")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: crontask run [--config crontasks.yml] [--format yaml] [--watch] [--shutdown-timeout 30s] [--log-file crontask.log] [--log-level info] [--log-json] [--metrics-addr :9090] [--admin-addr 127.0.0.1:8080]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var out io.Writer = os.Stdout
+	if *logFile != "" {
+		f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		out = io.MultiWriter(os.Stdout, f)
+	}
+	logger, err := newLogger(out, *logLevel, *logJSON)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	// The engine loads and schedules all the tasks of the file
+	engine := crontask.NewCronTaskEngine(crontask.Config{
+		TasksPath:      *config,
+		Format:         *format,
+		WatchTasksFile: *watch,
+		Logger:         logger,
+		AdminAddr:      *adminAddr,
+		AdminToken:     os.Getenv("CRONTASK_ADMIN_TOKEN"),
+	})
+
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", engine.MetricsHandler())
+		go func() {
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				logger.Error("metrics server stopped", "addr", *metricsAddr, "error", err)
+			}
+		}()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, slices.Concat(shutdownSignals, reloadSignals, statusSignals)...)
+
+	logger.Info("cron server started, press Ctrl+C to stop")
+
+	for sig := range signals {
+		switch {
+		case slices.Contains(reloadSignals, sig):
+			if err := engine.Reload(); err != nil {
+				logger.Error("reload failed", "error", err)
+			}
+		case slices.Contains(statusSignals, sig):
+			engine.LogStatus()
+		default:
+			return shutdown(engine, signals, *shutdownTimeout)
+		}
+	}
+	return 0
+}
+
+// shutdown waits for the running tasks, a second signal kills them right away
+func shutdown(engine *crontask.CronTaskEngine, signals <-chan os.Signal, timeout time.Duration) int {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := engine.Shutdown(ctx); err != nil {
+		engine.Logger().Error("shutdown failed", "error", err)
+		return 1
+	}
+	return 0
+}
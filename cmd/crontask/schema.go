@@ -0,0 +1,41 @@
+//go:build !wasm
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cdvelop/crontask"
+)
+
+// schema writes the JSON Schema of the tasks file, for editors and CI
+//
+//	crontask schema [-o crontasks.schema.json]
+func schema(args []string) int {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	output := fs.String("o", "-", "output file, - for stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: crontask schema [-o crontasks.schema.json]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if *output == "-" {
+		os.Stdout.Write(crontask.JSONSchema())
+		return 0
+	}
+	if err := os.WriteFile(*output, crontask.JSONSchema(), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println("Schema written to", *output)
+	return 0
+}
@@ -0,0 +1,15 @@
+//go:build !wasm && !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals handled by the daemon
+var (
+	shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	reloadSignals   = []os.Signal{syscall.SIGHUP}
+	statusSignals   = []os.Signal{syscall.SIGUSR1}
+)
@@ -0,0 +1,16 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals handled by the daemon, windows has no SIGHUP nor SIGUSR1:
+// reload with --watch instead
+var (
+	shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	reloadSignals   []os.Signal
+	statusSignals   []os.Signal
+)
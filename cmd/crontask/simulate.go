@@ -0,0 +1,129 @@
+//go:build !wasm
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cdvelop/crontask"
+)
+
+// simulatedRun is a run of the simulate --json output
+type simulatedRun struct {
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Task       string    `json:"task"`
+	Workflow   string    `json:"workflow,omitempty"`
+	After      string    `json:"after,omitempty"`
+	Overlap    bool      `json:"overlap"`
+	Concurrent int       `json:"concurrent"`
+}
+
+// simulate prints the runs a tasks file would start in a time window, without executing them
+//
+//	crontask simulate [--config crontasks.yml] [--format yaml] [--from now] [--to +24h] [--duration 1m] [--json]
+func simulate(args []string) int {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	config := fs.String("config", "crontasks.yml", "tasks file, directory or glob")
+	format := fs.String("format", "", "format of the tasks files: yaml, json, toml or crontab, default: from the file extension")
+	from := fs.String("from", "", `start of the window: "now", "2006-01-02", "2006-01-02 15:04" or RFC 3339, default: now`)
+	to := fs.String("to", "", `end of the window, excluded: a time like --from or "+<duration>" after --from, eg: "+24h", default: "+24h"`)
+	duration := fs.Duration("duration", time.Minute, "assumed run time of every task")
+	asJSON := fs.Bool("json", false, "print JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: crontask simulate [--config crontasks.yml] [--format yaml] [--from now] [--to +24h] [--duration 1m] [--json]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	start := time.Now()
+	if *from != "" {
+		if start, err = parseTime(*from, start); err != nil {
+			fmt.Fprintln(os.Stderr, "invalid --from:", err)
+			return 2
+		}
+	}
+	end := start.Add(24 * time.Hour)
+	if *to != "" {
+		if end, err = parseTime(*to, start); err != nil {
+			fmt.Fprintln(os.Stderr, "invalid --to:", err)
+			return 2
+		}
+	}
+
+	tasks, workflows, err := crontask.LoadTasks(*config, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	firings, err := crontask.Simulate(tasks, workflows, start, end, crontask.SimulateOptions{Duration: *duration})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *asJSON {
+		runs := make([]simulatedRun, len(firings))
+		for i, f := range firings {
+			runs[i] = simulatedRun(f)
+		}
+		printJSON(runs)
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "START\tEND\tTASK\tCONCURRENT\tNOTES")
+	overlaps, maxConcurrent := 0, 0
+	for _, f := range firings {
+		notes := ""
+		if f.Workflow != "" {
+			notes += "workflow " + f.Workflow + " "
+		}
+		if f.After != "" {
+			notes += "after " + f.After + " "
+		}
+		if f.Overlap {
+			notes += "OVERLAPS its previous run"
+			overlaps++
+		}
+		maxConcurrent = max(maxConcurrent, f.Concurrent)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", f.Start.Format("2006-01-02 15:04"), f.End.Format("15:04"), f.Task, f.Concurrent, notes)
+	}
+	w.Flush()
+	fmt.Fprintf(os.Stdout, "\n%d runs from %s to %s, at most %d at once, %d overlapping their previous run\n",
+		len(firings), start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"), maxConcurrent, overlaps)
+	return 0
+}
+
+// parseTime accepts "now", "+<duration>" after base, a date, a date and time
+// or RFC 3339, in local time unless it has a zone
+func parseTime(s string, base time.Time) (time.Time, error) {
+	if s == "now" {
+		return time.Now(), nil
+	}
+	if after, ok := strings.CutPrefix(s, "+"); ok {
+		d, err := time.ParseDuration(after)
+		if err != nil || d <= 0 {
+			return time.Time{}, fmt.Errorf("%q is not a positive duration, eg: +24h or +90m", s)
+		}
+		return base.Add(d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not now, +<duration>, a date, a date and time or RFC 3339", s)
+}
@@ -0,0 +1,83 @@
+//go:build !wasm
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSimulateRelativeTo(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "crontasks.yml")
+	yml := "- name: hourly\n  schedule: \"0 * * * *\"\n  command: echo\n"
+	if err := os.WriteFile(config, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The form shown by the usage: --to +<duration> after --from
+	out, code := captureStdout(t, func() int {
+		return simulate([]string{"--config", config, "--from", "2025-03-02", "--to", "+3h", "--json"})
+	})
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	var runs []simulatedRun
+	if err := json.Unmarshal(out, &runs); err != nil {
+		t.Fatalf("%v:\n%s", err, out)
+	}
+	from := time.Date(2025, 3, 2, 0, 0, 0, 0, time.Local)
+	if len(runs) != 3 || !runs[0].Start.Equal(from) || !runs[2].Start.Equal(from.Add(2*time.Hour)) {
+		t.Errorf("runs %+v, want 00:00, 01:00 and 02:00", runs)
+	}
+
+	for _, to := range []string{"+", "+-1h", "+1d", "tomorrow"} {
+		if _, code := captureStdout(t, func() int { return simulate([]string{"--config", config, "--to", to}) }); code != 2 {
+			t.Errorf("--to %q: exit code %d, want 2", to, code)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	base := time.Date(2025, 3, 2, 6, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"+24h":                 base.Add(24 * time.Hour),
+		"+90m":                 base.Add(90 * time.Minute),
+		"2025-03-03":           time.Date(2025, 3, 3, 0, 0, 0, 0, time.Local),
+		"2025-03-03 07:30":     time.Date(2025, 3, 3, 7, 30, 0, 0, time.Local),
+		"2025-03-03T07:30:00Z": time.Date(2025, 3, 3, 7, 30, 0, 0, time.UTC),
+	}
+	for in, want := range tests {
+		got, err := parseTime(in, base)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseTime(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	if now, err := parseTime("now", base); err != nil || time.Since(now) > time.Minute {
+		t.Errorf("parseTime(now) = %v, %v", now, err)
+	}
+}
+
+// captureStdout returns what fn writes to the standard output and its exit code
+func captureStdout(t *testing.T, fn func() int) ([]byte, int) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, w
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	read := make(chan []byte)
+	go func() {
+		out, _ := io.ReadAll(r)
+		read <- out
+	}()
+	code := fn()
+	w.Close()
+	return <-read, code
+}
@@ -0,0 +1,76 @@
+//go:build !wasm
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cdvelop/crontask"
+)
+
+// validateProblem is a problem of the validate --json output
+type validateProblem struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// validate checks a tasks file and prints every problem found
+//
+//	crontask validate [--format yaml] [--json] [crontasks.yml]
+func validate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	format := fs.String("format", "", "format of the tasks files: yaml, json, toml or crontab, default: from the file extension")
+	asJSON := fs.Bool("json", false, "print JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: crontask validate [--format yaml] [--json] [crontasks.yml]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) > 1 {
+		fs.Usage()
+		return 2
+	}
+	path := "crontasks.yml"
+	if len(positional) == 1 {
+		path = positional[0]
+	}
+
+	tasks, workflows, err := crontask.LoadTasks(path, *format)
+
+	problems := []validateProblem{}
+	var validationErrs crontask.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, e := range validationErrs {
+			problems = append(problems, validateProblem{File: e.File, Line: e.Line, Column: e.Column, Message: e.Message})
+		}
+	case err != nil:
+		problems = append(problems, validateProblem{Message: err.Error()})
+	}
+
+	if *asJSON {
+		printJSON(struct {
+			Valid     bool              `json:"valid"`
+			Tasks     int               `json:"tasks"`
+			Workflows int               `json:"workflows"`
+			Problems  []validateProblem `json:"problems"`
+		}{len(problems) == 0, len(tasks), len(workflows), problems})
+	} else if err != nil {
+		fmt.Fprintln(os.Stdout, err)
+	} else {
+		fmt.Fprintf(os.Stdout, "%s is valid: %d tasks, %d workflows\n", path, len(tasks), len(workflows))
+	}
+
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
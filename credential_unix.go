@@ -0,0 +1,103 @@
+//go:build !wasm && !windows
+
+package crontask
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// taskCredential resolves the user: and group: fields of a task into the
+// credentials the child process will run with. It returns nil when the task
+// runs as the engine's own user.
+func taskCredential(t Task) (*syscall.Credential, *user.User, error) {
+	if t.User == "" && t.Group == "" {
+		return nil, nil, nil
+	}
+
+	cred := &syscall.Credential{
+		Uid: uint32(os.Geteuid()),
+		Gid: uint32(os.Getegid()),
+	}
+
+	var u *user.User
+	if t.User != "" {
+		var err error
+		if u, err = lookupUser(t.User); err != nil {
+			return nil, nil, newErr("task", t.Name, "unknown user", t.User+":", err)
+		}
+		uid, _ := strconv.Atoi(u.Uid)
+		gid, _ := strconv.Atoi(u.Gid)
+		cred.Uid, cred.Gid = uint32(uid), uint32(gid)
+
+		// Drop the engine's supplementary groups in favour of the user's own
+		groupIds, _ := u.GroupIds()
+		for _, g := range groupIds {
+			if id, err := strconv.Atoi(g); err == nil {
+				cred.Groups = append(cred.Groups, uint32(id))
+			}
+		}
+	}
+
+	if t.Group != "" {
+		g, err := lookupGroup(t.Group)
+		if err != nil {
+			return nil, nil, newErr("task", t.Name, "unknown group", t.Group+":", err)
+		}
+		gid, _ := strconv.Atoi(g.Gid)
+		cred.Gid = uint32(gid)
+	}
+
+	if os.Geteuid() != 0 && (int(cred.Uid) != os.Geteuid() || int(cred.Gid) != os.Getegid()) {
+		return nil, nil, newErr("task", t.Name, "runs as another user or group, which requires root privileges but the engine runs as uid", os.Geteuid())
+	}
+
+	return cred, u, nil
+}
+
+// checkCredential validates the user: and group: fields at load time
+func checkCredential(t Task) error {
+	_, _, err := taskCredential(t)
+	return err
+}
+
+// setCredential makes cmd run with the task's user and group
+func setCredential(cmd *exec.Cmd, t Task) error {
+	cred, u, err := taskCredential(t)
+	if err != nil || cred == nil {
+		return err
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = cred
+
+	if u != nil {
+		// Same as cron: the child sees the environment of its own user
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, "HOME="+u.HomeDir, "USER="+u.Username, "LOGNAME="+u.Username)
+	}
+	return nil
+}
+
+// lookupUser accepts a user name or a numeric uid
+func lookupUser(name string) (*user.User, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		return user.LookupId(name)
+	}
+	return user.Lookup(name)
+}
+
+// lookupGroup accepts a group name or a numeric gid
+func lookupGroup(name string) (*user.Group, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		return user.LookupGroupId(name)
+	}
+	return user.LookupGroup(name)
+}
@@ -0,0 +1,50 @@
+//go:build !wasm && !windows
+
+package crontask
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCheckCredential(t *testing.T) {
+	if err := checkCredential(Task{Name: "plain"}); err != nil {
+		t.Errorf("task without user should be valid: %v", err)
+	}
+
+	if err := checkCredential(Task{Name: "ghost", User: "no_such_user_crontask"}); err == nil {
+		t.Error("unknown user should be rejected at load time")
+	}
+
+	if err := checkCredential(Task{Name: "ghost", Group: "no_such_group_crontask"}); err == nil {
+		t.Error("unknown group should be rejected at load time")
+	}
+
+	if os.Geteuid() != 0 {
+		if err := checkCredential(Task{Name: "as_root", User: "0"}); err == nil || !strings.Contains(err.Error(), "root privileges") {
+			t.Errorf("expected a privileges error, got %v", err)
+		}
+	}
+}
+
+func TestSetCredentialRunsAsUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("switching user requires root")
+	}
+
+	task := Task{Name: "as_nobody", User: "nobody"}
+	cmd := exec.Command("id", "-un")
+	if err := setCredential(cmd, task); err != nil {
+		t.Skip("nobody user not available:", err)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("id failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "nobody" {
+		t.Errorf("command ran as %q, want nobody", got)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !wasm && windows
+
+package crontask
+
+import "os/exec"
+
+// checkCredential validates the user: and group: fields at load time
+func checkCredential(t Task) error {
+	if t.User != "" || t.Group != "" {
+		return newErr("task", t.Name, "user and group are not supported on windows")
+	}
+	return nil
+}
+
+// setCredential makes cmd run with the task's user and group
+func setCredential(cmd *exec.Cmd, t Task) error {
+	return checkCredential(t)
+}
@@ -1,7 +1,7 @@
 package crontask
 
 import (
-	"log"
+	"log/slog"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -13,12 +13,16 @@ import (
 // crontab struct representing cron table
 type crontab struct {
 	ticker *time.Ticker
+	done   chan struct{} // closed by Stop
 	jobs   []*job
+	logger *slog.Logger // reports the panics of the jobs
 	sync.RWMutex
 }
 
 // job in cron table
 type job struct {
+	id string // optional, lets RemoveJob find the job
+
 	min       map[int]struct{}
 	hour      map[int]struct{}
 	day       map[int]struct{}
@@ -48,18 +52,37 @@ func newCrontab() *crontab {
 func new(t time.Duration) *crontab {
 	c := &crontab{
 		ticker: time.NewTicker(t),
+		done:   make(chan struct{}),
 		jobs:   []*job{},
+		logger: slog.Default(),
 	}
 
 	go func() {
-		for t := range c.ticker.C {
-			c.runScheduled(t)
+		for {
+			select {
+			case t := <-c.ticker.C:
+				c.runScheduled(t)
+			case <-c.done:
+				return
+			}
 		}
 	}()
 
 	return c
 }
 
+// Stop ends the ticker, no job runs anymore. It can be called more than once.
+func (c *crontab) Stop() {
+	c.Lock()
+	defer c.Unlock()
+	select {
+	case <-c.done:
+	default:
+		c.ticker.Stop()
+		close(c.done)
+	}
+}
+
 // AddJob to cron table.
 //
 // Returns error if:
@@ -70,6 +93,11 @@ func new(t time.Duration) *crontab {
 //
 // * Provided args don't match the number and/or the type of fn args
 func (c *crontab) AddJob(schedule string, fn any, args ...any) error {
+	return c.AddJobWithID("", schedule, fn, args...)
+}
+
+// AddJobWithID is like AddJob but tags the job with an id so it can be removed later with RemoveJob
+func (c *crontab) AddJobWithID(id, schedule string, fn any, args ...any) error {
 	j, err := parseSchedule(schedule)
 	c.Lock()
 	defer c.Unlock()
@@ -102,6 +130,7 @@ func (c *crontab) AddJob(schedule string, fn any, args ...any) error {
 	}
 
 	// all checked, add job to cron tab
+	j.id = id
 	j.fn = fn
 	j.args = args
 	c.jobs = append(c.jobs, j)
@@ -140,15 +169,35 @@ func (c *crontab) Clear() {
 	c.Unlock()
 }
 
+// RemoveJob removes the jobs added with the given id, runs already in progress are not affected
+func (c *crontab) RemoveJob(id string) {
+	c.Lock()
+	defer c.Unlock()
+	jobs := c.jobs[:0]
+	for _, j := range c.jobs {
+		if j.id != id {
+			jobs = append(jobs, j)
+		}
+	}
+	c.jobs = jobs
+}
+
 // RunAll jobs in cron table, shcheduled or not
 func (c *crontab) RunAll() {
 	c.RLock()
 	defer c.RUnlock()
 	for _, j := range c.jobs {
-		go j.run()
+		go j.run(c.logger)
 	}
 }
 
+// setLogger replaces the logger of the panics
+func (c *crontab) setLogger(logger *slog.Logger) {
+	c.Lock()
+	c.logger = logger
+	c.Unlock()
+}
+
 // RunScheduled jobs
 func (c *crontab) runScheduled(t time.Time) {
 	tick := getTick(t)
@@ -157,18 +206,18 @@ func (c *crontab) runScheduled(t time.Time) {
 
 	for _, j := range c.jobs {
 		if j.tick(tick) {
-			go j.run()
+			go j.run(c.logger)
 		}
 	}
 }
 
 // run the job using reflection
 // Recover from panic although all functions and params are checked by AddJob, but you never know.
-func (j *job) run() {
+func (j *job) run(logger *slog.Logger) {
 	j.RLock()
 	defer func() {
 		if r := recover(); r != nil {
-			log.Println("crontab error", r)
+			logger.Error("crontab job panicked", "job", j.id, "panic", r)
 		}
 	}()
 	v := reflect.ValueOf(j.fn)
@@ -278,7 +327,14 @@ func parsePart(s string, min, max int) (map[int]struct{}, error) {
 	}
 
 	// */2 1-59/5 pattern
-	if matches := matchN.FindStringSubmatch(s); matches != nil {
+	if matches := matchN.FindStringSubmatch(s); matches != nil || strings.Contains(s, "/") {
+		if matches == nil || matches[0] != s {
+			return nil, newErr("Unable to parse step in", s)
+		}
+		n, err := strconv.Atoi(matches[2])
+		if err != nil || n <= 0 {
+			return nil, newErr("Invalid step", matches[2], "in", s, "must be greater than 0")
+		}
 		localMin := min
 		localMax := max
 		if matches[1] != "" && matches[1] != "*" {
@@ -292,7 +348,6 @@ func parsePart(s string, min, max int) (map[int]struct{}, error) {
 				return nil, newErr("Unable to parse", matches[1], "part in", s)
 			}
 		}
-		n, _ := strconv.Atoi(matches[2])
 		for i := localMin; i <= localMax; i += n {
 			r[i] = struct{}{}
 		}
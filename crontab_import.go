@@ -0,0 +1,246 @@
+package crontask
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// crontabSpecials are the @ shortcuts of the classic crontab format
+var crontabSpecials = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	crontabMonths   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	crontabWeekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+	matchEnvLine    = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+	matchDow7Range  = regexp.MustCompile(`^(\d)-7$`)
+)
+
+// ParseCrontab converts a classic crontab into tasks. withUser is true for
+// /etc/crontab and /etc/cron.d files, where a user field follows the schedule.
+//
+// Every line becomes a task named after the comment just above it, or after
+// its line number. Environment assignments (NAME=value) apply to the lines
+// that follow them, SHELL selects the shell that runs the command line, and
+// an unescaped % ends the command: the rest is written to its standard input
+// with every other % turned into a newline.
+func ParseCrontab(data []byte, withUser bool) (Tasks, error) {
+	file, problems := parseCrontab(data, "", withUser)
+	if err := problems.err(); err != nil {
+		return nil, err
+	}
+	return file.Tasks, nil
+}
+
+// isCrontabPath reports whether a tasks path is in crontab format and whether
+// it has the user field of the system crontabs
+func isCrontabPath(path string) (isCrontab, withUser bool) {
+	base := filepath.Base(path)
+	switch {
+	case base == "crontab":
+		return true, filepath.Dir(path) != "." && filepath.Base(filepath.Dir(path)) == "etc"
+	case filepath.Base(filepath.Dir(path)) == "cron.d":
+		return true, true
+	case strings.HasSuffix(base, ".crontab") || strings.HasSuffix(base, ".cron"):
+		return true, false
+	}
+	return false, false
+}
+
+// IsSystemCrontab reports whether path is /etc/crontab or a file of a cron.d
+// directory, whose lines have a user field after the schedule
+func IsSystemCrontab(path string) bool {
+	_, withUser := isCrontabPath(path)
+	return withUser
+}
+
+func parseCrontab(data []byte, file string, withUser bool) (taskFile, ValidationErrors) {
+	tf := taskFile{positions: make(map[string]position)}
+	var problems ValidationErrors
+	env := make(map[string]string)
+	shell := "/bin/sh"
+	names := make(map[string]bool)
+	comment := ""
+
+	for i, raw := range strings.Split(string(data), "\n") {
+		lineNumber := i + 1
+		line := strings.TrimSpace(strings.TrimSuffix(raw, "\r"))
+		problem := func(message string) {
+			problems = append(problems, ValidationError{File: file, Line: lineNumber, Column: 1, Message: message})
+		}
+
+		switch {
+		case line == "":
+			comment = ""
+			continue
+		case strings.HasPrefix(line, "#"):
+			comment = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+
+		if m := matchEnvLine.FindStringSubmatch(line); m != nil {
+			value := unquoteEnv(m[2])
+			if m[1] == "SHELL" {
+				shell = value
+			} else {
+				env[m[1]] = value
+			}
+			comment = ""
+			continue
+		}
+
+		schedule, rest, err := crontabSchedule(line)
+		if err != nil {
+			problem(err.Error())
+			comment = ""
+			continue
+		}
+
+		task := Task{Schedule: schedule}
+		if fields := strings.Fields(rest); withUser && len(fields) > 0 {
+			task.User = fields[0]
+			rest = strings.TrimSpace(strings.TrimPrefix(rest, fields[0]))
+		}
+		if rest == "" {
+			problem("crontab line without command")
+			comment = ""
+			continue
+		}
+
+		commandLine, stdin := splitCrontabCommand(rest)
+		task.Command = shell
+		task.Args = shellArgs(commandLine)
+		task.Stdin = stdin
+		if len(env) > 0 {
+			task.Env = make(map[string]string, len(env))
+			for k, v := range env {
+				task.Env[k] = v
+			}
+		}
+
+		task.Name = comment
+		if task.Name == "" || names[task.Name] {
+			task.Name = strings.TrimSpace(task.Name + " line " + strconv.Itoa(lineNumber))
+		}
+		names[task.Name] = true
+		comment = ""
+
+		path := "tasks[" + strconv.Itoa(len(tf.Tasks)) + "]"
+		tf.positions[path] = position{file: file, line: lineNumber, column: 1}
+		tf.positions[path+".schedule"] = position{file: file, line: lineNumber, column: 1}
+		tf.Tasks = append(tf.Tasks, task)
+	}
+
+	return tf, problems
+}
+
+// crontabSchedule splits the schedule from the rest of a crontab line and
+// rewrites it to the syntax understood by parseSchedule
+func crontabSchedule(line string) (schedule, rest string, err error) {
+	fields := strings.Fields(line)
+	if strings.HasPrefix(fields[0], "@") {
+		schedule, ok := crontabSpecials[strings.ToLower(fields[0])]
+		if !ok {
+			return "", "", newErr("unsupported crontab schedule", fields[0])
+		}
+		return schedule, strings.TrimSpace(strings.TrimPrefix(line, fields[0])), nil
+	}
+
+	if len(fields) < 6 {
+		return "", "", newErr("crontab line must have five schedule fields and a command")
+	}
+
+	parts := fields[:5]
+	parts[3] = replaceNames(parts[3], crontabMonths, 1)
+	parts[4] = replaceNames(parts[4], crontabWeekdays, 0)
+	parts[4] = sundayAsZero(parts[4])
+	schedule = strings.Join(parts, " ")
+	if _, err := parseSchedule(schedule); err != nil {
+		return "", "", err
+	}
+
+	// The command keeps its original spacing
+	rest = line
+	for range 5 {
+		rest = strings.TrimLeft(rest, " \t")
+		rest = rest[strings.IndexAny(rest+" ", " \t"):]
+	}
+	return schedule, strings.TrimSpace(rest), nil
+}
+
+// replaceNames turns "jan-mar" or "mon,fri" into numbers, first being the number of names[0]
+func replaceNames(field string, names []string, first int) string {
+	lower := strings.ToLower(field)
+	for i, name := range names {
+		lower = strings.ReplaceAll(lower, name, strconv.Itoa(i+first))
+	}
+	return lower
+}
+
+// sundayAsZero rewrites the day of week 7, which crontab accepts for sunday
+func sundayAsZero(field string) string {
+	parts := strings.Split(field, ",")
+	for i, p := range parts {
+		switch {
+		case p == "7":
+			parts[i] = "0"
+		case matchDow7Range.MatchString(p):
+			parts[i] = p[:len(p)-1] + "6,0"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitCrontabCommand applies the % rule: the first unescaped % ends the command
+// and the rest, with every other % as a newline, is the standard input
+func splitCrontabCommand(s string) (command, stdin string) {
+	var out strings.Builder
+	inStdin := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '%':
+			out.WriteByte('%')
+			i++
+		case s[i] == '%' && !inStdin:
+			command = out.String()
+			out.Reset()
+			inStdin = true
+		case s[i] == '%':
+			out.WriteByte('\n')
+		default:
+			out.WriteByte(s[i])
+		}
+	}
+	if !inStdin {
+		return out.String(), ""
+	}
+	return command, out.String() + "\n"
+}
+
+// shellArgs builds the args for "<shell> -c <command line>": in single quotes
+// when the command line has none, else in double quotes with its \ and "
+// escaped for splitArgs
+func shellArgs(commandLine string) string {
+	if !strings.Contains(commandLine, "'") {
+		return "-c '" + commandLine + "'"
+	}
+	return `-c "` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(commandLine) + `"`
+}
+
+// unquoteEnv removes the optional quotes around an environment value
+func unquoteEnv(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
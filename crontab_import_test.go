@@ -0,0 +1,127 @@
+package crontask
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSystemCrontab = `# /etc/crontab: system-wide crontab
+SHELL=/bin/bash
+PATH=/usr/local/sbin:/usr/local/bin:/sbin:/bin:/usr/sbin:/usr/bin
+
+# Rotate the logs
+25 6	* * *	root	test -x /usr/sbin/anacron || run-parts --report /etc/cron.daily
+MAILTO="ops@example.com"
+0 3 * jan-mar mon-fri backup /opt/backup.sh --full
+@hourly www-data php /var/www/cron.php
+# Weekly report
+0 9 * * 7 reports mail -s "Weekly \% report" ops%Hello,%see attached
+`
+
+func TestParseCrontab(t *testing.T) {
+	tasks, err := ParseCrontab([]byte(testSystemCrontab), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := map[string]string{"PATH": "/usr/local/sbin:/usr/local/bin:/sbin:/bin:/usr/sbin:/usr/bin"}
+	pathAndMail := map[string]string{"PATH": path["PATH"], "MAILTO": "ops@example.com"}
+	want := Tasks{
+		{Name: "Rotate the logs", Schedule: "25 6 * * *", User: "root", Command: "/bin/bash",
+			Args: "-c 'test -x /usr/sbin/anacron || run-parts --report /etc/cron.daily'", Env: path},
+		{Name: "line 8", Schedule: "0 3 * 1-3 1-5", User: "backup", Command: "/bin/bash",
+			Args: "-c '/opt/backup.sh --full'", Env: pathAndMail},
+		{Name: "line 9", Schedule: "0 * * * *", User: "www-data", Command: "/bin/bash",
+			Args: "-c 'php /var/www/cron.php'", Env: pathAndMail},
+		{Name: "Weekly report", Schedule: "0 9 * * 0", User: "reports", Command: "/bin/bash",
+			Args: `-c 'mail -s "Weekly % report" ops'`, Stdin: "Hello,\nsee attached\n", Env: pathAndMail},
+	}
+
+	if len(tasks) != len(want) {
+		t.Fatalf("got %d tasks, want %d: %+v", len(tasks), len(want), tasks)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(tasks[i], want[i]) {
+			t.Errorf("task %d\n got %+v\nwant %+v", i, tasks[i], want[i])
+		}
+	}
+}
+
+func TestParseCrontabErrors(t *testing.T) {
+	_, err := ParseCrontab([]byte("@reboot /opt/start.sh\n* * * * *\n0 25 * * * /bin/true\n"), false)
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	problems := err.(ValidationErrors)
+	if len(problems) != 3 || problems[0].Line != 1 || problems[1].Line != 2 || problems[2].Line != 3 {
+		t.Errorf("expected one problem per line, got:\n%v", err)
+	}
+}
+
+func TestConvertCrontab(t *testing.T) {
+	yml, err := ConvertCrontab([]byte("# Backup\n0 1 * * * /opt/backup.sh%config\n"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `- name: "Backup"
+  schedule: "0 1 * * *"
+  command: "/bin/sh"
+  args: "-c '/opt/backup.sh'"
+  stdin: "config\n"
+`
+	if string(yml) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", yml, want)
+	}
+	if strings.Contains(string(yml), "success_codes") {
+		t.Error("empty fields should be left out")
+	}
+}
+
+func TestParseCrontabMixedQuotes(t *testing.T) {
+	lines := []string{
+		`tar czf "/backup/site-$(date +'%F').tgz" /var/www`,
+		`sh -c 'pg_dump app > /backup/app-$(date +"%F").sql'`,
+		`find /tmp -name '*.log' -mtime +7 -exec rm {} \;`,
+		`echo "it's \"done\"" | logger -t 'cron job'`,
+		`printf 'a\tb\n' "C:\Temp"`,
+	}
+	for _, line := range lines {
+		crontab := strings.ReplaceAll(line, "%", `\%`)
+		tasks, err := ParseCrontab([]byte("0 1 * * * "+crontab+"\n"), false)
+		if err != nil {
+			t.Errorf("%s: %v", line, err)
+			continue
+		}
+		if got := splitArgs(tasks[0].Args); !reflect.DeepEqual(got, []string{"-c", line}) {
+			t.Errorf("%s: args %q split into %q", line, tasks[0].Args, got)
+		}
+
+		// The converted file reads back the same command line
+		yml, _ := ConvertCrontab([]byte("0 1 * * * "+crontab+"\n"), false)
+		file, err := parseTaskData(yml, "crontasks.yml", FormatYAML)
+		if err != nil || len(file.Tasks) != 1 || file.Tasks[0].Args != tasks[0].Args {
+			t.Errorf("%s: converted file reads back %+v %v", line, file.Tasks, err)
+		}
+	}
+}
+
+func TestParseCrontabMissingCommand(t *testing.T) {
+	if _, err := ParseCrontab([]byte("@daily root\n@weekly\n"), true); err == nil {
+		t.Error("expected missing command errors")
+	}
+}
+
+func TestIsSystemCrontab(t *testing.T) {
+	for path, want := range map[string]bool{
+		"/etc/crontab":         true,
+		"/etc/cron.d/backup":   true,
+		"crontab":              false,
+		"/home/ana/my.crontab": false,
+		"/srv/crontasks.yml":   false,
+	} {
+		if got := IsSystemCrontab(path); got != want {
+			t.Errorf("%s: got %v want %v", path, got, want)
+		}
+	}
+}
@@ -2,40 +2,157 @@ package crontask
 
 import (
 	"fmt"
+	"log/slog"
+	"net/http"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type cronAdapter interface {
 	AddProgramTask(schedule string, fn any, args ...any) error
-	GetTasksFromPath(tasksPath string) ([]Tasks, error)
-	ExecuteCmd(cmd Task) error
+	ScheduleJob(id, schedule string, fn func()) error // like AddProgramTask, removable with UnscheduleJob
+	UnscheduleJob(id string)
+	GetTasksFromPath(tasksPath, format string) (taskFile, error) // format "" detects it from each file name
+	ExecuteCmd(cmd Task) (RunResult, error)
 	GetBasePath() string // without / eg: "path/to/base"
 	RunAllAdapterTasks()
-	Log(...any) // Logger function
 }
 
 const filePathDefault = "crontasks.yml"
 
 type Tasks []Task
 
+// taskFile is the content of a tasks file, either a plain list of tasks
+// or a mapping with "include:", "defaults:", "templates:", "tasks:" and
+// "workflows:" keys
+type taskFile struct {
+	Include   []string        `yaml:"include"`   // eg: ["teams/*.yml"] other task files, relative to this one
+	Defaults  Task            `yaml:"defaults"`  // fields every task starts from
+	Templates map[string]Task `yaml:"templates"` // eg: {"db_job": {...}} named fields tasks can extend
+	Tasks     []Task          `yaml:"tasks"`
+	Workflows []Workflow      `yaml:"workflows"`
+
+	sources   []string            // files read to build this task set
+	secrets   []string            // values read from ${file:...} secrets
+	positions map[string]position // eg: "tasks[2].schedule" => where the value is written
+}
+
 type Task struct {
 	Name     string `yaml:"name"`     // eg: "Backup system"
 	Schedule string `yaml:"schedule"` // eg: "0 7 * * 1,4" (2 times a week, monday and thursday)
 	Command  string `yaml:"command"`  // eg: "C:\Program Files\FreeFileSync\FreeFileSync.exe"
 	Args     string `yaml:"args"`     // eg: "D:\Backup\SystemBackup.ffs_batch"
+	WorkDir  string `yaml:"workdir"`  // eg: "/srv/app" working directory of the command, default: the current one
+	Timeout  string `yaml:"timeout"`  // eg: "30m" the command is killed when it runs longer
+	Extends  string `yaml:"extends"`  // eg: "db_job" template of templates: this task starts from
+
+	OnSuccess  []string `yaml:"on_success"`  // eg: ["upload_dump"] tasks run after a successful run
+	OnFailure  []string `yaml:"on_failure"`  // eg: ["notify_admin"] tasks run after a failed run
+	OnComplete []string `yaml:"on_complete"` // eg: ["prune_dumps"] tasks run after every run
+
+	SuccessCodes   []int  `yaml:"success_codes"`   // eg: [0, 1] exit codes treated as success, default: [0]
+	SkipCodes      []int  `yaml:"skip_codes"`      // eg: [3] exit codes meaning "nothing to do"
+	FailOnStderr   bool   `yaml:"fail_on_stderr"`  // mark the run as failed when the command writes to stderr
+	SuccessPattern string `yaml:"success_pattern"` // eg: "Backup completed" regex that must appear in the output
+
+	User  string `yaml:"user"`  // eg: "backup" user name or uid to run the command as (native, requires root)
+	Group string `yaml:"group"` // eg: "backup" group name or gid, default: the user's primary group
+
+	Nice         int    `yaml:"nice"`           // eg: 10 scheduling priority from -20 to 19 (linux)
+	IONice       string `yaml:"ionice"`         // eg: "idle", "best-effort:7" or "realtime:0" (linux)
+	MaxMemory    string `yaml:"max_memory"`     // eg: "512M" address space limit (linux)
+	MaxCPUTime   string `yaml:"max_cpu_time"`   // eg: "10m" CPU time before the process is killed (linux)
+	MaxOpenFiles int    `yaml:"max_open_files"` // eg: 256 open file descriptors (linux)
+
+	Stdin     string `yaml:"stdin"`      // eg: "{\"mode\": \"full\"}" text written to the command's standard input
+	StdinFile string `yaml:"stdin_file"` // eg: "/etc/backup/config.json" file streamed to the command's standard input
+
+	Env map[string]string `yaml:"env"` // eg: {"PGHOST": "db1"} variables added to the command's environment
+
+	env      []string // extra "KEY=value" variables for this run eg: CRONTASK_PREV_EXIT of a chain
+	workflow string   // workflow this run belongs to, if any
+	source   string   // file the task was loaded from
+	secrets  []string // interpolated secret values, masked in logs and history
+	fn       func()   // Go function of AddTaskSchedule, run instead of a command
+
+	successRe *regexp.Regexp // SuccessPattern compiled when the tasks are loaded
 }
 
 // Config contains all configuration options for the CronTaskEngine
 type Config struct {
-	TasksPath      string // Path to tasks file, default: "crontasks.yml"
-	NoAutoSchedule bool   // Set to true to disable automatic task scheduling
-	testFolderPath string // Base path for execution and file lookup eg: "test/uc01_test", default: ""
+	TasksPath      string        // Path to a tasks file, a directory or a glob eg: "/etc/crontask.d/*.yml", default: "crontasks.yml"
+	Format         string        // Format of the tasks files: "yaml", "json", "toml" or "crontab", default: detected from the file extension
+	Tasks          []Task        // Tasks already loaded eg: by LoadTasks, used instead of reading TasksPath on start
+	Workflows      []Workflow    // Workflows loaded with Tasks
+	NoAutoSchedule bool          // Set to true to disable automatic task scheduling
+	WatchTasksFile bool          // Reload the tasks file when it changes on disk
+	WatchInterval  time.Duration // Polling interval where file notifications are unavailable, default: 5s
+	ReloadOnSIGHUP bool          // Reload the tasks file when the process receives SIGHUP (unix)
+	Logger         *slog.Logger  // Structured logger, default: text on stdout at level Info
+	LogFile        string        // Also write the default logger to this file eg: "log.txt", default: none
+	Hooks          Hooks         // Callbacks around every run
+	Middleware     []Middleware  // Wrappers of every run, the first one is the outermost
+	AdminAddr      string        // Serve the admin HTTP API at this address eg: "127.0.0.1:8080", default: off
+	AdminToken     string        // Bearer token required by the admin API, it doesn't start without one
+	testFolderPath string        // Base path for execution and file lookup eg: "test/uc01_test", default: ""
 }
 
 type CronTaskEngine struct {
-	adapter cronAdapter
-	tasks   []Task
-	Log     func(...any) // Logger function
+	// Log writes its arguments, separated by spaces, as an Info record of Logger.
+	//
+	// Deprecated: use Logger. The engine no longer writes its own messages
+	// through Log, replacing it doesn't capture them, set Config.Logger instead.
+	Log func(...any)
+
+	adapter   cronAdapter
+	tasksPath string
+	format    string       // Config.Format
+	sources   []string     // files read on the last load, watched by WatchTasksFile
+	logger    *slog.Logger // Config.Logger masking the secrets
+	quit      chan struct{}
+
+	sourcesChanged chan struct{} // a reload read other files, the watcher restarts on them
+
+	hooks      Hooks        // Config.Hooks
+	middleware []Middleware // Config.Middleware
+
+	subsMu sync.RWMutex
+	subs   []*Subscription // receivers of the events
+
+	metrics metrics // counters of the finished runs
+
+	pausedMu sync.RWMutex
+	paused   map[string]bool // "task:<name>" and "workflow:<name>" whose scheduled runs are skipped
+
+	started    time.Time    // when the engine was created
+	adminToken string       // Config.AdminToken
+	admin      *http.Server // serves Config.AdminAddr
+	heartbeat  atomic.Int64 // unix nanoseconds of the last heartbeat job, checked by /health
+
+	reloadMu sync.Mutex // one Reload at a time: the file watcher, SIGHUP and the admin API may overlap
+
+	mu           sync.RWMutex
+	tasks        []Task
+	workflows    []Workflow
+	scheduled    bool // tasks and workflows were added to the adapter, reloads must reschedule them
+	autoSchedule bool // Config.NoAutoSchedule unset: a reload schedules the tasks even when the first load failed
+
+	historyMu sync.RWMutex
+	history   []RunResult // last historySize runs, oldest first
+
+	secretsMu sync.RWMutex
+	secrets   []string // secret values of the loaded tasks, masked in the log
+
+	runMu     sync.Mutex
+	running   map[int64]RunningTask // runs in progress by run id
+	lastRunID int64
+	runWG     sync.WaitGroup // runs in progress, waited for by Shutdown
+	stopping  bool           // Shutdown was called, no new run starts
 }
 
 // NewCronTaskEngine creates a new CronTaskEngine instance.
@@ -55,103 +172,333 @@ func NewCronTaskEngine(configs ...Config) *CronTaskEngine {
 		config = configs[0]
 	}
 
+	c := &CronTaskEngine{
+		tasks:          make([]Task, 0),
+		quit:           make(chan struct{}),
+		sourcesChanged: make(chan struct{}, 1),
+		hooks:          config.Hooks,
+		middleware:     config.Middleware,
+		started:        time.Now(),
+		adminToken:     config.AdminToken,
+		autoSchedule:   !config.NoAutoSchedule,
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = newDefaultLogger(config.LogFile)
+	}
+	c.logger = slog.New(secretsHandler{logger.Handler(), c})
+	c.Log = func(args ...any) {
+		c.logger.Info(strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+	}
+
 	// The adapter initialization is handled by build-specific files
-	a := newCronAdapter()
+	a := newCronAdapter(c.logger)
+	c.adapter = a
 
 	var testFolderPath string
 	if config.testFolderPath != "" {
 		testFolderPath = config.testFolderPath
 	}
 
-	c := &CronTaskEngine{
-		adapter: a,
-		tasks:   make([]Task, 0),
-		Log:     a.Log,
-	}
-
 	// Set default tasks path if not provided
 	pathTasks := filePathDefault
 	if config.TasksPath != "" {
 		pathTasks = config.TasksPath
 	}
 
-	fullPath := filepath.Join(a.GetBasePath(), testFolderPath, pathTasks)
-	c.Log("Loading tasks from", fullPath)
+	fullPath := pathTasks
+	if !filepath.IsAbs(pathTasks) {
+		fullPath = filepath.Join(a.GetBasePath(), testFolderPath, pathTasks)
+	}
+	c.tasksPath = fullPath
+	c.format = config.Format
 
-	ts, err := a.GetTasksFromPath(fullPath)
+	var file taskFile
+	var err error
+	if config.Tasks != nil {
+		// Validated again, the tasks may be built in code. The validation
+		// compiles success_pattern into the tasks: a copy, not the caller's slice
+		file = taskFile{Tasks: slices.Clone(config.Tasks), Workflows: config.Workflows}
+		for _, t := range file.Tasks {
+			file.secrets = append(file.secrets, t.secrets...)
+		}
+		var checkTask func(Task) error
+		if r, ok := a.(taskFileReader); ok {
+			checkTask = r.checkTask
+		}
+		err = validateTaskFile(file, checkTask).err()
+	} else {
+		c.logger.Info("loading tasks", "path", fullPath)
+		file, err = a.GetTasksFromPath(fullPath, c.format)
+	}
 	if err != nil {
-		c.Log("No tasks loaded from path:", fullPath, "Error:", err)
+		c.logger.Error("no tasks loaded", "path", fullPath, "error", err)
 	} else {
-		for _, t := range ts {
-			c.tasks = append(c.tasks, t...)
-		}
+		c.tasks = append(c.tasks, file.Tasks...)
+		c.workflows = append(c.workflows, file.Workflows...)
+		c.sources = file.sources
+		c.setSecrets(file.secrets)
 
 		// Display loaded tasks
-		for i, task := range c.tasks {
-			c.Log(fmt.Sprintf("Task %d: %s (Schedule: %s)", i+1, task.Name, task.Schedule))
+		for _, task := range c.tasks {
+			c.logger.Info("task loaded", "task", task.Name, "schedule", task.Schedule, "description", scheduleDescription(task.Schedule))
+		}
+		for _, wf := range c.workflows {
+			c.logger.Info("workflow loaded", "workflow", wf.Name, "schedule", wf.Schedule, "description", scheduleDescription(wf.Schedule), "steps", len(wf.Steps))
 		}
 	}
 
 	// Auto-schedule tasks unless explicitly disabled
 	if !config.NoAutoSchedule {
 		if err := c.ScheduleAllTasks(); err != nil {
-			c.Log("Error scheduling tasks:", err)
+			c.logger.Error("scheduling tasks failed", "error", err)
 		} else {
-			c.Log("All tasks scheduled successfully")
+			c.logger.Info("all tasks scheduled")
+		}
+	}
+
+	if config.WatchTasksFile {
+		c.watchTasksFile(config.WatchInterval)
+	}
+	if config.ReloadOnSIGHUP {
+		c.reloadOnSignal()
+	}
+	if config.AdminAddr != "" {
+		if err := c.startAdmin(config.AdminAddr); err != nil {
+			c.logger.Error("admin API failed", "addr", config.AdminAddr, "error", err)
 		}
 	}
 
 	return c
 }
 
-// AddJob adds a new scheduled job to the cron task
+// AddJob adds a new scheduled job to the cron task. A func() runs like a
+// task named after the function eg: "main.backup", with the hooks, the
+// middleware and the history of the tasks.
 func (c *CronTaskEngine) AddTaskSchedule(schedule string, fn any, args ...any) error {
-	c.Log("Adding job with schedule:", schedule)
+	c.logger.Debug("adding job", "schedule", schedule)
+	if f, ok := fn.(func()); ok && len(args) == 0 {
+		task := Task{Name: funcName(f), Schedule: schedule, fn: f}
+		if err := c.adapter.AddProgramTask(schedule, func() { c.runScheduled(task) }); err != nil {
+			return err
+		}
+		c.emit(Event{Type: EventScheduled, Task: task.Name, Schedule: schedule})
+		return nil
+	}
 	return c.adapter.AddProgramTask(schedule, fn, args...)
 }
 
-// ScheduleAllTasks schedules all loaded tasks to be executed according to their schedule
+// ScheduleAllTasks schedules all loaded tasks and workflows to be executed according
+// to their schedule. It is all-or-nothing: every schedule is checked first and if
+// one can't be added the ones already added are removed again.
 func (c *CronTaskEngine) ScheduleAllTasks() error {
-	if len(c.tasks) == 0 {
+	tasks, workflows := c.GetTasks(), c.GetWorkflows()
+	if len(tasks) == 0 {
 		return newErr("no tasks to schedule")
 	}
 
-	c.Log("Scheduling", len(c.tasks), "tasks")
-	for _, task := range c.tasks {
-		taskCopy := task // Create a copy to avoid closure issues
-		c.Log("Scheduling task:", task.Name, "with schedule:", task.Schedule)
-		err := c.adapter.AddProgramTask(task.Schedule, func() {
-			c.Log("Executing scheduled task:", taskCopy.Name)
-			c.adapter.ExecuteCmd(taskCopy)
-		})
-		if err != nil {
-			c.Log("Error scheduling task:", task.Name, "Error:", err)
+	var problems ValidationErrors
+	for _, task := range tasks {
+		if _, err := parseSchedule(task.Schedule); task.Schedule != "" && err != nil {
+			problems = append(problems, ValidationError{File: task.source, Message: "task " + task.Name + " invalid schedule: " + err.Error()})
+		}
+	}
+	for _, wf := range workflows {
+		if _, err := parseSchedule(wf.Schedule); wf.Schedule != "" && err != nil {
+			problems = append(problems, ValidationError{Message: "workflow " + wf.Name + " invalid schedule: " + err.Error()})
+		}
+	}
+	if err := problems.err(); err != nil {
+		return err
+	}
+
+	c.logger.Debug("scheduling tasks", "count", len(tasks))
+	var added []string
+	rollback := func() {
+		for _, id := range added {
+			c.adapter.UnscheduleJob(id)
+		}
+	}
+	for _, task := range tasks {
+		if err := c.scheduleTask(task); err != nil {
+			c.logger.Error("scheduling task failed", "task", task.Name, "error", err)
+			rollback()
 			return err
 		}
+		added = append(added, "task:"+task.Name)
 	}
+
+	for _, wf := range workflows {
+		if err := c.scheduleWorkflow(wf); err != nil {
+			c.logger.Error("scheduling workflow failed", "workflow", wf.Name, "error", err)
+			rollback()
+			return err
+		}
+		added = append(added, "workflow:"+wf.Name)
+	}
+
+	c.mu.Lock()
+	c.scheduled = true
+	c.mu.Unlock()
 	return nil
 }
 
+// scheduleTask adds a task to the adapter under the id "task:<name>"
+func (c *CronTaskEngine) scheduleTask(task Task) error {
+	if task.Schedule == "" {
+		c.logger.Debug("task has no schedule, it only runs as a follow-up or on demand", "task", task.Name)
+		return nil
+	}
+	c.logger.Debug("scheduling task", "task", task.Name, "schedule", task.Schedule)
+	err := c.adapter.ScheduleJob("task:"+task.Name, task.Schedule, func() {
+		c.runScheduled(task)
+	})
+	if err == nil {
+		c.emit(Event{Type: EventScheduled, Task: task.Name, Schedule: task.Schedule})
+	}
+	return err
+}
+
+// scheduleWorkflow adds a workflow to the adapter under the id "workflow:<name>"
+func (c *CronTaskEngine) scheduleWorkflow(wf Workflow) error {
+	if wf.Schedule == "" {
+		return nil
+	}
+	c.logger.Debug("scheduling workflow", "workflow", wf.Name, "schedule", wf.Schedule)
+	err := c.adapter.ScheduleJob("workflow:"+wf.Name, wf.Schedule, func() {
+		if c.isPaused("workflow:" + wf.Name) {
+			c.logger.Info("workflow paused, skipping its scheduled run", "workflow", wf.Name)
+			return
+		}
+		if err := c.RunWorkflow(wf.Name); err != nil {
+			c.logger.Error("workflow failed", "workflow", wf.Name, "error", err)
+		}
+	})
+	if err == nil {
+		c.emit(Event{Type: EventScheduled, Workflow: wf.Name, Schedule: wf.Schedule})
+	}
+	return err
+}
+
 // RunAll executes all scheduled tasks immediately
 func (c *CronTaskEngine) RunAllTasks() {
-	c.Log("Running all scheduled tasks")
+	c.logger.Info("running all scheduled tasks")
 	c.adapter.RunAllAdapterTasks()
 }
 
 // ExecuteTask executes a specific task by its name
 func (c *CronTaskEngine) ExecuteTask(taskName string) error {
-	c.Log("Executing task:", taskName)
-	for _, task := range c.tasks {
-		if task.Name == taskName {
-			return c.adapter.ExecuteCmd(task)
+	_, err := c.RunTask(taskName)
+	return err
+}
+
+// RunTask executes a task by its name, like ExecuteTask, and returns its result
+func (c *CronTaskEngine) RunTask(taskName string) (RunResult, error) {
+	task, ok := c.findTask(taskName)
+	if !ok {
+		return RunResult{}, newErr("task not found: " + taskName)
+	}
+	return c.runTask(task)
+}
+
+// findTask returns the loaded task with the given name
+func (c *CronTaskEngine) findTask(name string) (Task, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return taskByName(c.tasks, name)
+}
+
+func taskByName(tasks []Task, name string) (Task, bool) {
+	for _, task := range tasks {
+		if task.Name == name {
+			return task, true
 		}
 	}
-	return newErr("task not found: " + taskName)
+	return Task{}, false
 }
 
 // GetTasks returns a copy of all loaded tasks
 func (c *CronTaskEngine) GetTasks() []Task {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	tasksCopy := make([]Task, len(c.tasks))
 	copy(tasksCopy, c.tasks)
 	return tasksCopy
 }
+
+// runTask executes a task and its follow-up tasks
+func (c *CronTaskEngine) runTask(task Task) (RunResult, error) {
+	return c.runChained(task, nil)
+}
+
+// runChained executes a task through the adapter, records its result in the history
+// and then runs its follow-up tasks
+func (c *CronTaskEngine) runChained(task Task, chain []string) (RunResult, error) {
+	id, ok := c.startRun(task)
+	if !ok {
+		c.logger.Warn("task not started, engine shutting down", "task", task.Name)
+		return RunResult{Task: task.Name, Workflow: task.workflow, Status: StatusSkipped, ExitCode: -1, Reason: "engine shutting down"},
+			newErr("engine shutting down, task", task.Name, "not started")
+	}
+	runLog := c.logger.With("task", task.Name, "run_id", id)
+	if task.workflow != "" {
+		runLog = runLog.With("workflow", task.workflow)
+	}
+	runLog.Info("task started")
+	c.emit(Event{Type: EventStarted, Task: task.Name, Workflow: task.workflow, RunID: id})
+
+	result, err := c.execute(task)
+	c.endRun(id)
+	result.RunID, result.Workflow = id, task.workflow
+	if len(task.secrets) > 0 {
+		result.Output = maskSecrets(result.Output, task.secrets)
+		result.Reason = maskSecrets(result.Reason, task.secrets)
+		if err != nil {
+			err = newErr(maskSecrets(err.Error(), task.secrets))
+		}
+	}
+	attrs := []any{"status", string(result.Status), "exit_code", result.ExitCode, "duration", result.Duration}
+	switch result.Status {
+	case StatusFailed:
+		runLog.Error("task failed", append(attrs, "reason", result.Reason, "output", result.Output)...)
+	case StatusSkipped:
+		runLog.Info("task skipped", append(attrs, "reason", result.Reason)...)
+	default:
+		runLog.Info("task succeeded", attrs...)
+	}
+	if result.Output != "" && result.Status != StatusFailed {
+		runLog.Debug("task output", "output", result.Output)
+	}
+	c.finished(task, result, err)
+
+	c.runFollowUps(task, result, append(chain, task.Name))
+	return result, err
+}
+
+// finished records a run in the history and the metrics, then tells the
+// subscribers and the hooks
+func (c *CronTaskEngine) finished(task Task, result RunResult, err error) {
+	c.record(result)
+	c.metrics.observe(result)
+	c.emitFinished(result)
+	c.hooks.finished(task, result, err)
+}
+
+// record appends a run to the history, keeping only the last historySize runs
+func (c *CronTaskEngine) record(result RunResult) {
+	c.historyMu.Lock()
+	defer c.historyMu.Unlock()
+	c.history = append(c.history, result)
+	if len(c.history) > historySize {
+		c.history = c.history[len(c.history)-historySize:]
+	}
+}
+
+// History returns a copy of the most recent task runs, oldest first
+func (c *CronTaskEngine) History() []RunResult {
+	c.historyMu.RLock()
+	defer c.historyMu.RUnlock()
+	historyCopy := make([]RunResult, len(c.history))
+	copy(historyCopy, c.history)
+	return historyCopy
+}
@@ -0,0 +1,77 @@
+package crontask
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+// dashboardFiles is the web dashboard of the admin API: plain HTML, CSS and
+// JavaScript without external assets, it works offline
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// eventsKeepAlive is how often the event stream writes a comment so proxies
+// don't close an idle connection
+const eventsKeepAlive = 30 * time.Second
+
+// dashboardHandler serves the page at / and its files at /ui/. The page
+// asks for the admin token and calls the API with it.
+func dashboardHandler() (index, files http.Handler) {
+	sub, _ := fs.Sub(dashboardFiles, "dashboard")
+	page, _ := fs.ReadFile(sub, "index.html")
+
+	secure := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("Referrer-Policy", "no-referrer")
+			next.ServeHTTP(w, r)
+		})
+	}
+	index = secure(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	}))
+	files = secure(http.StripPrefix("/ui/", http.FileServerFS(sub)))
+	return index, files
+}
+
+// adminEvents streams the events of Subscribe as server-sent events until
+// the client leaves or the engine shuts down
+func (c *CronTaskEngine) adminEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	sub := c.Subscribe()
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case e := <-sub.C:
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		case <-c.quit:
+			return // Shutdown waits for the open connections
+		}
+		flusher.Flush()
+	}
+}
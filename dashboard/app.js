@@ -0,0 +1,253 @@
+// crontask dashboard: reads the admin API with the token kept in the
+// session, refreshes every few seconds and right away on engine events.
+"use strict";
+
+const refreshInterval = 5000;
+const historyShown = 20;
+
+let token = sessionStorage.getItem("crontask-token") || "";
+let timer = null;
+let events = null; // AbortController of the event stream
+let refreshing = false;
+
+const $ = (id) => document.getElementById(id);
+
+// el builds an element, children are nodes or text: never HTML, task output included
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [key, value] of Object.entries(attrs || {})) {
+    if (key.startsWith("on")) {
+      node.addEventListener(key.slice(2), value);
+    } else if (value !== undefined && value !== null && value !== false) {
+      node.setAttribute(key, value === true ? "" : value);
+    }
+  }
+  for (const child of children.flat()) {
+    if (child !== undefined && child !== null) {
+      node.append(child instanceof Node ? child : String(child));
+    }
+  }
+  return node;
+}
+
+function badge(text, kind) {
+  return el("span", { class: "badge " + (kind || text) }, text);
+}
+
+function formatTime(value) {
+  if (!value) return "-";
+  const d = new Date(value);
+  const today = new Date();
+  const time = d.toLocaleTimeString([], { hour: "2-digit", minute: "2-digit", second: "2-digit" });
+  return d.toDateString() === today.toDateString() ? time : d.toLocaleDateString() + " " + time;
+}
+
+function formatDuration(seconds) {
+  if (seconds === undefined || seconds === null) return "-";
+  if (seconds < 1) return Math.round(seconds * 1000) + "ms";
+  if (seconds < 60) return seconds.toFixed(1) + "s";
+  const m = Math.floor(seconds / 60);
+  if (m < 60) return m + "m " + Math.round(seconds % 60) + "s";
+  return Math.floor(m / 60) + "h " + (m % 60) + "m";
+}
+
+function showMessage(text) {
+  $("message").textContent = text;
+  $("message").hidden = !text;
+}
+
+async function api(method, path) {
+  const response = await fetch(path, { method, headers: { Authorization: "Bearer " + token } });
+  if (response.status === 401) {
+    disconnect("Invalid admin token");
+    throw new Error("unauthorized");
+  }
+  const body = await response.json();
+  if (!response.ok) {
+    throw new Error(body.error || response.statusText);
+  }
+  return body;
+}
+
+// action runs, pauses or resumes a task or workflow then refreshes
+async function action(kind, name, verb) {
+  try {
+    await api("POST", `api/${kind}/${encodeURIComponent(name)}/${verb}`);
+    showMessage("");
+  } catch (err) {
+    showMessage(`${verb} ${name}: ${err.message}`);
+  }
+  refresh();
+}
+
+function actionButtons(kind, item) {
+  return el("td", { class: "actions" },
+    el("button", { onclick: () => action(kind, item.name, "run") }, "Run"), " ",
+    item.paused
+      ? el("button", { onclick: () => action(kind, item.name, "resume") }, "Resume")
+      : el("button", { onclick: () => action(kind, item.name, "pause") }, "Pause"),
+  );
+}
+
+function renderTasks(tasks) {
+  $("tasks").replaceChildren(...tasks.map((t) => {
+    const last = t.last_run;
+    let outcome = "-";
+    if (t.running > 0) {
+      outcome = badge("running");
+    } else if (last) {
+      outcome = el("span", { title: last.reason || "" }, badge(last.status), last.exit_code > 0 ? ` exit ${last.exit_code}` : "");
+    }
+    return el("tr", { class: t.paused ? "paused" : null },
+      el("td", {}, t.name, t.paused ? [" ", badge("paused")] : []),
+      el("td", { title: t.description || "" }, t.schedule ? el("code", {}, t.schedule) : el("span", { class: "muted" }, "on demand")),
+      el("td", {}, t.paused ? "-" : formatTime(t.next_run)),
+      el("td", {}, outcome),
+      el("td", {}, last ? `${formatTime(last.start)} (${formatDuration(last.duration_seconds)})` : "-"),
+      actionButtons("tasks", t),
+    );
+  }));
+}
+
+function renderWorkflows(workflows) {
+  $("workflows-section").hidden = workflows.length === 0;
+  $("workflows").replaceChildren(...workflows.map((wf) => el("tr", { class: wf.paused ? "paused" : null },
+    el("td", {}, wf.name, wf.paused ? [" ", badge("paused")] : []),
+    el("td", { title: wf.description || "" }, wf.schedule ? el("code", {}, wf.schedule) : el("span", { class: "muted" }, "on demand")),
+    el("td", {}, wf.paused ? "-" : formatTime(wf.next_run)),
+    el("td", {}, wf.steps.map((s) => s.task).join(", ")),
+    actionButtons("workflows", wf),
+  )));
+}
+
+function renderStatus(status) {
+  $("running-count").textContent = status.running.length ? `(${status.running.length})` : "";
+  if (status.running.length === 0) {
+    $("running").replaceChildren(el("tr", {}, el("td", { colspan: 5, class: "muted" }, "Nothing running")));
+  } else {
+    $("running").replaceChildren(...status.running.map((r) => el("tr", {},
+      el("td", {}, r.task), el("td", {}, r.workflow || "-"), el("td", {}, "#" + r.run_id),
+      el("td", {}, formatTime(r.start)), el("td", {}, formatDuration(r.elapsed_seconds)),
+    )));
+  }
+  $("uptime").textContent = "up " + formatDuration(status.uptime_seconds);
+}
+
+function renderHistory(runs) {
+  if (runs.length === 0) {
+    $("history").replaceChildren(el("p", { class: "muted" }, "No runs yet"));
+    return;
+  }
+  // Keep open the outputs the user expanded
+  const open = new Set([...$("history").querySelectorAll("details[open]")].map((d) => d.dataset.key));
+  $("history").replaceChildren(...runs.slice().reverse().map((r) => {
+    const key = r.task + "#" + (r.run_id || r.start);
+    return el("details", { "data-key": key, open: open.has(key) },
+      el("summary", {}, badge(r.status), `${formatTime(r.start)} ${r.task}`,
+        r.workflow ? el("span", { class: "muted" }, ` (${r.workflow})`) : "",
+        el("span", { class: "muted" }, ` ${formatDuration(r.duration_seconds)}${r.reason ? " - " + r.reason : ""}`)),
+      el("pre", {}, r.output || "(no output)"),
+    );
+  }));
+}
+
+async function refreshHealth() {
+  try {
+    const response = await fetch("health");
+    const health = await response.json();
+    $("health").replaceWith(Object.assign(badge(health.status), { id: "health" }));
+  } catch (err) {
+    $("health").replaceWith(Object.assign(badge("down"), { id: "health" }));
+  }
+}
+
+async function refresh() {
+  refreshHealth();
+  if (!token || refreshing) return;
+  refreshing = true;
+  try {
+    const [list, status, history] = await Promise.all([
+      api("GET", "api/tasks"),
+      api("GET", "api/status"),
+      api("GET", "api/history?limit=" + historyShown),
+    ]);
+    renderTasks(list.tasks);
+    renderWorkflows(list.workflows);
+    renderStatus(status);
+    renderHistory(history);
+    $("app").hidden = false;
+  } catch (err) {
+    if (err.message !== "unauthorized") showMessage("Refresh failed: " + err.message);
+  } finally {
+    refreshing = false;
+  }
+}
+
+// listen reads the event stream and refreshes on every event, a few at a time
+async function listen() {
+  events = new AbortController();
+  const signal = events.signal;
+  let pending = null;
+  try {
+    const response = await fetch("api/events", { headers: { Authorization: "Bearer " + token }, signal });
+    if (!response.ok) return;
+    const reader = response.body.getReader();
+    const decoder = new TextDecoder();
+    let buffer = "";
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) break;
+      buffer += decoder.decode(value, { stream: true });
+      let end;
+      while ((end = buffer.indexOf("\n\n")) >= 0) {
+        const message = buffer.slice(0, end);
+        buffer = buffer.slice(end + 2);
+        if (message.startsWith(":")) continue; // keep-alive
+        clearTimeout(pending);
+        pending = setTimeout(refresh, 200);
+      }
+    }
+  } catch (err) {
+    if (signal.aborted) return;
+  }
+  // The stream ended eg: the daemon restarted, try again later
+  if (!signal.aborted) setTimeout(listen, refreshInterval);
+}
+
+function connect() {
+  $("login").hidden = true;
+  $("logout").hidden = false;
+  showMessage("");
+  refresh();
+  clearInterval(timer);
+  timer = setInterval(refresh, refreshInterval);
+  listen();
+}
+
+function disconnect(message) {
+  token = "";
+  sessionStorage.removeItem("crontask-token");
+  clearInterval(timer);
+  timer = setInterval(refreshHealth, refreshInterval);
+  if (events) events.abort();
+  $("app").hidden = true;
+  $("login").hidden = false;
+  $("logout").hidden = true;
+  showMessage(message || "");
+}
+
+$("login").addEventListener("submit", (e) => {
+  e.preventDefault();
+  token = $("token").value.trim();
+  $("token").value = "";
+  if (!token) return;
+  sessionStorage.setItem("crontask-token", token);
+  connect();
+});
+$("logout").addEventListener("click", () => disconnect());
+
+if (token) {
+  connect();
+} else {
+  disconnect("Enter the admin token (Config.AdminToken) to connect");
+}
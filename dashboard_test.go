@@ -0,0 +1,87 @@
+package crontask
+
+import (
+	"bufio"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDashboard(t *testing.T) {
+	c := newFakeEngine(&fakeAdapter{})
+
+	// The page and its files need no token, the data comes from the API
+	code, body := adminRequest(t, c, "GET", "/", "")
+	if code != http.StatusOK || !strings.Contains(body, `<script src="ui/app.js">`) {
+		t.Errorf("index: %d %s", code, body)
+	}
+	for _, file := range []string{"/ui/app.js", "/ui/style.css"} {
+		if code, _ := adminRequest(t, c, "GET", file, ""); code != http.StatusOK {
+			t.Errorf("%s: %d", file, code)
+		}
+	}
+	if code, _ := adminRequest(t, c, "GET", "/missing", ""); code != http.StatusNotFound {
+		t.Errorf("unknown page: %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	c.AdminHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if csp := rec.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "default-src 'self'") {
+		t.Errorf("content security policy %q", csp)
+	}
+}
+
+func TestDashboardWorksOffline(t *testing.T) {
+	fs.WalkDir(dashboardFiles, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, _ := fs.ReadFile(dashboardFiles, path)
+		if strings.Contains(string(data), "http://") || strings.Contains(string(data), "https://") {
+			t.Errorf("%s loads an external resource", path)
+		}
+		return nil
+	})
+}
+
+func TestAdminEvents(t *testing.T) {
+	c := newFakeEngine(&fakeAdapter{}, Task{Name: "report"})
+	c.adminToken = "s3cret"
+	c.quit = make(chan struct{})
+	server := httptest.NewServer(c.AdminHandler())
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/api/events", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != ": connected" {
+		t.Fatalf("first line %q", lines.Text())
+	}
+	c.ExecuteTask("report")
+
+	var got []string
+	for lines.Scan() && len(got) < 2 {
+		if event, ok := strings.CutPrefix(lines.Text(), "event: "); ok {
+			got = append(got, event)
+		}
+	}
+	if strings.Join(got, " ") != "started succeeded" {
+		t.Errorf("events %v", got)
+	}
+
+	// Shutdown ends the stream
+	close(c.quit)
+	for lines.Scan() {
+	}
+}
@@ -0,0 +1,378 @@
+package crontask
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// scheduleWords are the words of a language used by Describe
+type scheduleWords struct {
+	and         string
+	rangeOf     string // range inside a list eg: "1 through 5"
+	singleRange string // a field that is just a range eg: "Monday through Friday"
+
+	days       [7]string // sunday first
+	daysInList [7]string // days after weekdays eg: "los lunes"
+	months     [12]string
+
+	everyMinute    string
+	everyNMinutes  string
+	minutesBetween string // every n minutes from minute a through b
+	atMinute       string
+	atMinutes      string
+	ofEveryHour    string
+	at             string
+	everyNHours    string
+	hoursBetween   string // every n hours from a through b
+	between        string // between two times
+	duringHours    string
+	everyNDays     string
+	daysBetween    string // every n days from day a through b
+	onDayOfMonth   string
+	onDaysOfMonth  string
+	onWeekdays     string
+	everyNMonths   string
+	inMonths       string
+	dayOrWeekday   string // day of month and day of week are combined
+}
+
+var scheduleLanguages = map[string]scheduleWords{
+	"en": {
+		and: " and ", rangeOf: "%s through %s", singleRange: "%s through %s",
+		days:       [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		daysInList: [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		months:     [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+
+		everyMinute:    "every minute",
+		everyNMinutes:  "every %d minutes",
+		minutesBetween: "every %d minutes from minute %d through %d",
+		atMinute:       "at minute %s",
+		atMinutes:      "at minutes %s",
+		ofEveryHour:    " of every hour",
+		at:             "at %s",
+		everyNHours:    "every %d hours",
+		hoursBetween:   "every %d hours from %s through %s",
+		between:        "between %s and %s",
+		duringHours:    "during hours %s",
+		everyNDays:     "every %d days",
+		daysBetween:    "every %d days from day %d through %d of the month",
+		onDayOfMonth:   "on day %s of the month",
+		onDaysOfMonth:  "on days %s of the month",
+		onWeekdays:     "on %s",
+		everyNMonths:   "every %d months",
+		inMonths:       "in %s",
+		dayOrWeekday:   "%s or %s",
+	},
+	"es": {
+		and: " y ", rangeOf: "%s a %s", singleRange: "de %s a %s",
+		days:       [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		daysInList: [7]string{"domingos", "lunes", "martes", "miércoles", "jueves", "viernes", "sábados"},
+		months:     [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+
+		everyMinute:    "cada minuto",
+		everyNMinutes:  "cada %d minutos",
+		minutesBetween: "cada %d minutos del minuto %d al %d",
+		atMinute:       "en el minuto %s",
+		atMinutes:      "en los minutos %s",
+		ofEveryHour:    " de cada hora",
+		at:             "a las %s",
+		everyNHours:    "cada %d horas",
+		hoursBetween:   "cada %d horas de %s a %s",
+		between:        "entre las %s y las %s",
+		duringHours:    "durante las horas %s",
+		everyNDays:     "cada %d días",
+		daysBetween:    "cada %d días del día %d al %d del mes",
+		onDayOfMonth:   "el día %s del mes",
+		onDaysOfMonth:  "los días %s del mes",
+		onWeekdays:     "los %s",
+		everyNMonths:   "cada %d meses",
+		inMonths:       "en %s",
+		dayOrWeekday:   "%s o %s",
+	},
+}
+
+// Describe returns a human readable description of a schedule in English
+// ("en", the default) or Spanish ("es")
+// eg: "5 14,19 */2 1-6 1-5" => "at 14:05 and 19:05, every 2 days or Monday through Friday, January through June"
+func Describe(schedule, lang string) (string, error) {
+	if lang == "" {
+		lang = "en"
+	}
+	w, ok := scheduleLanguages[lang]
+	if !ok {
+		return "", newErr("unsupported language", lang, "supported: en, es")
+	}
+	if err := crontabOnlySyntax(schedule); err != nil {
+		return "", err
+	}
+	j, err := parseSchedule(schedule)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(schedule)
+	for i, field := range fields {
+		fields[i] = withoutStepOne(field)
+	}
+	minute, hour, day, month, weekday := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	parts := []string{w.describeTime(minute, hour)}
+
+	// A day of month or a weekday, the same way the scheduler combines them
+	var days []string
+	if day != "*" {
+		days = append(days, w.describeDay(day))
+	}
+	if weekday != "*" {
+		days = append(days, w.describeWeekday(weekday, j))
+	}
+	switch len(days) {
+	case 1:
+		parts = append(parts, days[0])
+	case 2:
+		parts = append(parts, fmt.Sprintf(w.dayOrWeekday, days[0], days[1]))
+	}
+
+	if month != "*" {
+		parts = append(parts, w.describeMonth(month, j))
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// crontabOnlySyntax names the classic crontab syntax a task schedule doesn't
+// accept, @ shortcuts, month and day names and the day of week 7, with the
+// schedule to write instead, eg: "@daily" => use "0 0 * * *"
+func crontabOnlySyntax(schedule string) error {
+	fields := strings.Fields(schedule)
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "@") {
+		if equivalent, ok := crontabSpecials[strings.ToLower(fields[0])]; ok {
+			return newErr("unsupported shortcut", fields[0]+", use", strconv.Quote(equivalent))
+		}
+		return newErr("unsupported shortcut", fields[0])
+	}
+	if len(fields) != 5 {
+		return nil
+	}
+
+	var unsupported []string
+	converted := slices.Clone(fields)
+	converted[3] = replaceNames(fields[3], crontabMonths, 1)
+	converted[4] = replaceNames(fields[4], crontabWeekdays, 0)
+	if converted[3] != strings.ToLower(fields[3]) || converted[4] != strings.ToLower(fields[4]) {
+		unsupported = append(unsupported, "month and day names")
+	}
+	if sunday := sundayAsZero(converted[4]); sunday != converted[4] {
+		converted[4] = sunday
+		unsupported = append(unsupported, "day of week 7")
+	}
+	if len(unsupported) == 0 {
+		return nil
+	}
+	equivalent := strings.Join(converted, " ")
+	if _, err := parseSchedule(equivalent); err != nil {
+		return newErr("unsupported", strings.Join(unsupported, " and "), "in", strconv.Quote(schedule))
+	}
+	return newErr("unsupported", strings.Join(unsupported, " and "), "in", strconv.Quote(schedule)+", use", strconv.Quote(equivalent))
+}
+
+// scheduleDescription is the English description of a schedule for the
+// logs eg: "0 7 * * 1,4" => "at 07:00, on Monday and Thursday", empty when
+// the schedule is empty or invalid
+func scheduleDescription(schedule string) string {
+	description, err := Describe(schedule, "en")
+	if err != nil {
+		return ""
+	}
+	return description
+}
+
+func (w scheduleWords) describeTime(minute, hour string) string {
+	// Fixed times eg: "5 14,19" => at 14:05 and 19:05
+	minutes, minutesOK := fieldNumbers(minute)
+	hours, hoursOK := fieldNumbers(hour)
+	if minutesOK && hoursOK && len(minutes)*len(hours) <= 6 {
+		var times []string
+		for _, h := range hours {
+			for _, m := range minutes {
+				times = append(times, clock(h, m))
+			}
+		}
+		return fmt.Sprintf(w.at, w.list(times))
+	}
+
+	var minutePart string
+	switch n, from, to, isStep := fieldStep(minute); {
+	case minute == "*":
+		minutePart = w.everyMinute
+	case isStep && from < 0:
+		minutePart = fmt.Sprintf(w.everyNMinutes, n)
+	case isStep:
+		minutePart = fmt.Sprintf(w.minutesBetween, n, from, to)
+	case minutesOK && len(minutes) == 1:
+		minutePart = fmt.Sprintf(w.atMinute, minute)
+	default:
+		minutePart = fmt.Sprintf(w.atMinutes, w.items(minute, strconv.Itoa))
+	}
+
+	var hourPart string
+	switch n, from, to, isStep := fieldStep(hour); {
+	case hour == "*":
+		if !isStepOrAll(minute) {
+			minutePart += w.ofEveryHour
+		}
+		return minutePart
+	case isStep && from < 0:
+		hourPart = fmt.Sprintf(w.everyNHours, n)
+	case isStep:
+		hourPart = fmt.Sprintf(w.hoursBetween, n, clock(from, 0), clock(to, 59))
+	case hoursOK && len(hours) == 1:
+		hourPart = fmt.Sprintf(w.between, clock(hours[0], 0), clock(hours[0], 59))
+	case !strings.Contains(hour, ","):
+		from, to, _ := strings.Cut(hour, "-")
+		a, _ := strconv.Atoi(from)
+		b, _ := strconv.Atoi(to)
+		hourPart = fmt.Sprintf(w.between, clock(a, 0), clock(b, 59))
+	default:
+		hourPart = fmt.Sprintf(w.duringHours, w.items(hour, strconv.Itoa))
+	}
+	return minutePart + ", " + hourPart
+}
+
+func (w scheduleWords) describeDay(day string) string {
+	n, from, to, isStep := fieldStep(day)
+	switch {
+	case isStep && from < 0:
+		return fmt.Sprintf(w.everyNDays, n)
+	case isStep:
+		return fmt.Sprintf(w.daysBetween, n, from, to)
+	}
+	if days, ok := fieldNumbers(day); ok && len(days) == 1 {
+		return fmt.Sprintf(w.onDayOfMonth, day)
+	}
+	return fmt.Sprintf(w.onDaysOfMonth, w.items(day, strconv.Itoa))
+}
+
+func (w scheduleWords) describeWeekday(weekday string, j *job) string {
+	if isSingleRange(weekday) {
+		from, to, _ := strings.Cut(weekday, "-")
+		a, _ := strconv.Atoi(from)
+		b, _ := strconv.Atoi(to)
+		return fmt.Sprintf(w.singleRange, w.days[a], w.days[b])
+	}
+	name := func(n int) string { return w.daysInList[n] }
+	if _, _, _, isStep := fieldStep(weekday); isStep {
+		return fmt.Sprintf(w.onWeekdays, w.list(mapNames(j.dayOfWeek, name)))
+	}
+	return fmt.Sprintf(w.onWeekdays, w.items(weekday, name))
+}
+
+func (w scheduleWords) describeMonth(month string, j *job) string {
+	name := func(n int) string { return w.months[n-1] }
+	switch n, from, _, isStep := fieldStep(month); {
+	case isStep && from < 0:
+		return fmt.Sprintf(w.everyNMonths, n)
+	case isStep:
+		return fmt.Sprintf(w.inMonths, w.list(mapNames(j.month, name)))
+	}
+	if isSingleRange(month) {
+		from, to, _ := strings.Cut(month, "-")
+		a, _ := strconv.Atoi(from)
+		b, _ := strconv.Atoi(to)
+		return fmt.Sprintf(w.singleRange, name(a), name(b))
+	}
+	return fmt.Sprintf(w.inMonths, w.items(month, name))
+}
+
+// items formats a list field like "1,3-5,9" with a name for each number
+func (w scheduleWords) items(field string, name func(int) string) string {
+	var out []string
+	for _, item := range strings.Split(field, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		a, _ := strconv.Atoi(from)
+		if !isRange {
+			out = append(out, name(a))
+			continue
+		}
+		b, _ := strconv.Atoi(to)
+		out = append(out, fmt.Sprintf(w.rangeOf, name(a), name(b)))
+	}
+	return w.list(out)
+}
+
+// list joins "a", "b" and "c" as "a, b and c"
+func (w scheduleWords) list(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + w.and + items[len(items)-1]
+}
+
+// fieldNumbers returns the numbers of a field made only of single numbers eg: "5,30"
+func fieldNumbers(field string) ([]int, bool) {
+	var numbers []int
+	for _, item := range strings.Split(field, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, false
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, true
+}
+
+// fieldStep parses "*/n" (from -1) and "a-b/n"
+func fieldStep(field string) (n, from, to int, ok bool) {
+	prefix, step, found := strings.Cut(field, "/")
+	if !found {
+		return 0, 0, 0, false
+	}
+	n, _ = strconv.Atoi(step)
+	if prefix == "*" {
+		return n, -1, -1, true
+	}
+	a, b, _ := strings.Cut(prefix, "-")
+	from, _ = strconv.Atoi(a)
+	to, _ = strconv.Atoi(b)
+	return n, from, to, true
+}
+
+// withoutStepOne drops the step of 1, which repeats every value of the range
+// eg: "*/1" => "*" and "8-18/1" => "8-18", so it reads "every hour", not "every 1 hours"
+func withoutStepOne(field string) string {
+	items := strings.Split(field, ",")
+	for i, item := range items {
+		if prefix, step, found := strings.Cut(item, "/"); found {
+			if n, _ := strconv.Atoi(step); n == 1 {
+				items[i] = prefix
+			}
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+func isStepOrAll(field string) bool {
+	return field == "*" || strings.Contains(field, "/")
+}
+
+func isSingleRange(field string) bool {
+	return strings.Count(field, "-") == 1 && !strings.ContainsAny(field, ",/")
+}
+
+// mapNames names the numbers of a parsed schedule field in order
+func mapNames(values map[int]struct{}, name func(int) string) []string {
+	var numbers []int
+	for n := range values {
+		numbers = append(numbers, n)
+	}
+	slices.Sort(numbers)
+	names := make([]string, len(numbers))
+	for i, n := range numbers {
+		names[i] = name(n)
+	}
+	return names
+}
+
+func clock(hour, minute int) string {
+	return fmt.Sprintf("%02d:%02d", hour, minute)
+}
@@ -0,0 +1,81 @@
+package crontask
+
+import "testing"
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		schedule string
+		en, es   string
+	}{
+		{"* * * * *", "every minute", "cada minuto"},
+		{"*/15 * * * *", "every 15 minutes", "cada 15 minutos"},
+		{"5 * * * *", "at minute 5 of every hour", "en el minuto 5 de cada hora"},
+		{"0 7 * * 1,4", "at 07:00, on Monday and Thursday", "a las 07:00, los lunes y jueves"},
+		{"5 14,19 */2 1-6 1-5",
+			"at 14:05 and 19:05, every 2 days or Monday through Friday, January through June",
+			"a las 14:05 y 19:05, cada 2 días o de lunes a viernes, de enero a junio"},
+		{"*/5 9-17 * * 1-5",
+			"every 5 minutes, between 09:00 and 17:59, Monday through Friday",
+			"cada 5 minutos, entre las 09:00 y las 17:59, de lunes a viernes"},
+		{"0 8-18/2 * * *", "at minute 0, every 2 hours from 08:00 through 18:59", "en el minuto 0, cada 2 horas de 08:00 a 18:59"},
+		{"30 2 1,15 */3 *",
+			"at 02:30, on days 1 and 15 of the month, every 3 months",
+			"a las 02:30, los días 1 y 15 del mes, cada 3 meses"},
+		{"0 0 * * */2",
+			"at 00:00, on Sunday, Tuesday, Thursday and Saturday",
+			"a las 00:00, los domingos, martes, jueves y sábados"},
+		{"0-30 8,20 * * 0,6",
+			"at minutes 0 through 30, during hours 8 and 20, on Sunday and Saturday",
+			"en los minutos 0 a 30, durante las horas 8 y 20, los domingos y sábados"},
+		{"15 3 * 1-3,12 *", "at 03:15, in January through March and December", "a las 03:15, en enero a marzo y diciembre"},
+		// A step of 1 is every value
+		{"*/1 * * * *", "every minute", "cada minuto"},
+		{"0 */1 * * *", "at minute 0 of every hour", "en el minuto 0 de cada hora"},
+		{"0-30/1 9-17/1 * * *",
+			"at minutes 0 through 30, between 09:00 and 17:59",
+			"en los minutos 0 a 30, entre las 09:00 y las 17:59"},
+		{"0 6 */1 */1 *", "at 06:00", "a las 06:00"},
+		{"0 6 1-10/1 * 1-5/1",
+			"at 06:00, on days 1 through 10 of the month or Monday through Friday",
+			"a las 06:00, los días 1 a 10 del mes o de lunes a viernes"},
+	}
+	for _, tt := range tests {
+		for lang, want := range map[string]string{"en": tt.en, "es": tt.es} {
+			got, err := Describe(tt.schedule, lang)
+			if err != nil {
+				t.Errorf("%s %s: %v", lang, tt.schedule, err)
+			} else if got != want {
+				t.Errorf("%s %q:\n got  %q\n want %q", lang, tt.schedule, got, want)
+			}
+		}
+	}
+}
+
+func TestDescribeErrors(t *testing.T) {
+	if _, err := Describe("61 * * * *", "en"); err == nil {
+		t.Error("expected an error for minute 61")
+	}
+	if _, err := Describe("* * * * *", "fr"); err == nil {
+		t.Error("expected an error for an unsupported language")
+	}
+	// Crontab syntax the scheduler rejects is named, with what to write instead
+	crontabOnly := map[string]string{
+		"@daily":          `unsupported shortcut @daily, use "0 0 * * *"`,
+		"@HOURLY":         `unsupported shortcut @HOURLY, use "0 * * * *"`,
+		"@reboot":         "unsupported shortcut @reboot",
+		"0 7 * * 7":       `unsupported day of week 7 in "0 7 * * 7", use "0 7 * * 0"`,
+		"0 7 * * 5-7":     `unsupported day of week 7 in "0 7 * * 5-7", use "0 7 * * 5-6,0"`,
+		"0 7 * JAN MON":   `unsupported month and day names in "0 7 * JAN MON", use "0 7 * 1 1"`,
+		"0 7 * * sat-sun": `unsupported month and day names in "0 7 * * sat-sun"`,
+		"0 7 * * mon,7":   `unsupported month and day names and day of week 7 in "0 7 * * mon,7", use "0 7 * * 1,0"`,
+	}
+	for schedule, want := range crontabOnly {
+		if _, err := Describe(schedule, "en"); err == nil || err.Error() != want {
+			t.Errorf("%q: got error %v, want %s", schedule, err, want)
+		}
+	}
+
+	if got, _ := Describe("0 7 * * *", ""); got != "at 07:00" {
+		t.Errorf("default language = %q, want English", got)
+	}
+}
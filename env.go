@@ -0,0 +1,19 @@
+package crontask
+
+import "sort"
+
+// environ returns the env: variables of the task, sorted by name, followed by
+// the variables of this run
+func (t Task) environ() []string {
+	keys := make([]string, 0, len(t.Env))
+	for k := range t.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	vars := make([]string, 0, len(keys)+len(t.env))
+	for _, k := range keys {
+		vars = append(vars, k+"="+t.Env[k])
+	}
+	return append(vars, t.env...)
+}
@@ -0,0 +1,113 @@
+package crontask
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType is the kind of an Event
+type EventType string
+
+const (
+	EventScheduled      EventType = "scheduled"       // a task or workflow was added to the scheduler
+	EventStarted        EventType = "started"         // a run started
+	EventSucceeded      EventType = "succeeded"       // a run ended with success
+	EventFailed         EventType = "failed"          // a run failed
+	EventSkipped        EventType = "skipped"         // a run was skipped eg: skip code, BeforeRun or a failed dependency
+	EventTimedOut       EventType = "timed_out"       // a run was killed by its timeout, instead of EventFailed
+	EventRetrying       EventType = "retrying"        // a failed run is about to be retried, reserved: tasks have no retries yet
+	EventConfigReloaded EventType = "config_reloaded" // the tasks file was reloaded
+)
+
+// Event is a change in the state of the engine sent to the subscribers
+type Event struct {
+	Type     EventType  `json:"type"`
+	Time     time.Time  `json:"time"`
+	Task     string     `json:"task,omitempty"`
+	Workflow string     `json:"workflow,omitempty"` // the workflow scheduled, or the one of a step run
+	RunID    int64      `json:"run_id,omitempty"`
+	Schedule string     `json:"schedule,omitempty"` // EventScheduled
+	Result   *RunResult `json:"result,omitempty"`   // the end of a run
+	Added    []string   `json:"added,omitempty"`    // EventConfigReloaded, workflows as "workflow <name>"
+	Removed  []string   `json:"removed,omitempty"`  // EventConfigReloaded
+	Changed  []string   `json:"changed,omitempty"`  // EventConfigReloaded
+}
+
+// eventBuffer is the number of events a subscriber can fall behind before
+// the next ones are dropped
+const eventBuffer = 256
+
+// Subscription receives the events of the engine from Subscribe
+type Subscription struct {
+	C <-chan Event // the events, closed by Close
+
+	ch      chan Event
+	dropped atomic.Uint64
+	engine  *CronTaskEngine
+	once    sync.Once
+}
+
+// Subscribe returns a subscription to the events of the engine. Delivery
+// never blocks the engine: when the subscriber falls eventBuffer events
+// behind, the next ones are dropped and counted in Dropped. Close it when
+// done.
+func (c *CronTaskEngine) Subscribe() *Subscription {
+	ch := make(chan Event, eventBuffer)
+	s := &Subscription{C: ch, ch: ch, engine: c}
+	c.subsMu.Lock()
+	c.subs = append(c.subs, s)
+	c.subsMu.Unlock()
+	return s
+}
+
+// Dropped returns the number of events lost because the subscriber was too slow
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops the delivery of events and closes C
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		c := s.engine
+		c.subsMu.Lock()
+		for i, sub := range c.subs {
+			if sub == s {
+				c.subs = append(c.subs[:i], c.subs[i+1:]...)
+				break
+			}
+		}
+		c.subsMu.Unlock()
+		close(s.ch)
+	})
+}
+
+// emit sends an event to every subscriber without waiting for them
+func (c *CronTaskEngine) emit(e Event) {
+	e.Time = time.Now()
+	c.subsMu.RLock()
+	defer c.subsMu.RUnlock()
+	for _, s := range c.subs {
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// emitFinished sends the event of the end of a run
+func (c *CronTaskEngine) emitFinished(result RunResult) {
+	e := Event{Task: result.Task, Workflow: result.Workflow, RunID: result.RunID, Result: &result}
+	switch {
+	case result.TimedOut:
+		e.Type = EventTimedOut
+	case result.Status == StatusFailed:
+		e.Type = EventFailed
+	case result.Status == StatusSkipped:
+		e.Type = EventSkipped
+	default:
+		e.Type = EventSucceeded
+	}
+	c.emit(e)
+}
@@ -0,0 +1,95 @@
+package crontask
+
+import (
+	"slices"
+	"testing"
+)
+
+// drain returns the events waiting in a subscription
+func drain(s *Subscription) []Event {
+	var events []Event
+	for {
+		select {
+		case e, ok := <-s.C:
+			if !ok {
+				return events
+			}
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	a := &fakeAdapter{exitCodes: map[string]int{"bad": 1, "idle": 3}}
+	c := newFakeEngine(a,
+		Task{Name: "ok", Schedule: "0 1 * * *"},
+		Task{Name: "bad"},
+		Task{Name: "idle", SkipCodes: []int{3}},
+	)
+	s := c.Subscribe()
+	defer s.Close()
+
+	if err := c.ScheduleAllTasks(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"ok", "bad", "idle"} {
+		c.ExecuteTask(name)
+	}
+	a.file = taskFile{Tasks: []Task{{Name: "ok", Schedule: "0 2 * * *", Command: "echo"}, {Name: "new", Command: "echo"}}}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	events := drain(s)
+	for _, e := range events {
+		got = append(got, string(e.Type)+" "+e.Task)
+	}
+	want := []string{
+		"scheduled ok",
+		"started ok", "succeeded ok",
+		"started bad", "failed bad",
+		"started idle", "skipped idle",
+		"scheduled ok", "config_reloaded ",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("events\n%q\nwant\n%q", got, want)
+	}
+	if e := events[4]; e.Result == nil || e.Result.ExitCode != 1 || e.RunID != events[3].RunID {
+		t.Errorf("failed event %+v", e)
+	}
+	slices.Sort(events[8].Removed) // reload walks a map
+	if e := events[8]; !slices.Equal(e.Added, []string{"new"}) || !slices.Equal(e.Removed, []string{"bad", "idle"}) || !slices.Equal(e.Changed, []string{"ok"}) {
+		t.Errorf("reload event %+v", e)
+	}
+	if s.Dropped() != 0 {
+		t.Errorf("dropped %d", s.Dropped())
+	}
+}
+
+func TestSubscribeDropsForSlowConsumers(t *testing.T) {
+	c := newFakeEngine(&fakeAdapter{})
+	slow, fast := c.Subscribe(), c.Subscribe()
+
+	received := 0
+	for range eventBuffer + 5 {
+		c.emit(Event{Type: EventStarted})
+		received += len(drain(fast))
+	}
+	if slow.Dropped() != 5 || fast.Dropped() != 0 || received != eventBuffer+5 {
+		t.Errorf("slow dropped %d, fast dropped %d and received %d", slow.Dropped(), fast.Dropped(), received)
+	}
+
+	slow.Close()
+	slow.Close()
+	c.emit(Event{Type: EventStarted})
+	if n := len(drain(slow)); n != eventBuffer {
+		t.Errorf("closed subscription got %d events, want the %d buffered", n, eventBuffer)
+	}
+	if _, open := <-slow.C; open {
+		t.Error("channel still open after Close")
+	}
+	fast.Close()
+}
@@ -0,0 +1,102 @@
+package crontask
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Tasks file formats for Config.Format
+const (
+	FormatYAML    = "yaml"
+	FormatJSON    = "json"
+	FormatTOML    = "toml"
+	FormatCrontab = "crontab"
+)
+
+// nodeParsers build the document tree of the formats decoded by decodeTaskFile
+var nodeParsers = map[string]func(data []byte) (*node, error){
+	FormatYAML: parseYAMLNodes,
+	FormatJSON: parseJSONNodes,
+	FormatTOML: parseTOMLNodes,
+}
+
+// taskFileExtensions are the files taken from a conf.d style directory
+var taskFileExtensions = []string{".yml", ".yaml", ".json", ".toml"}
+
+// fileFormat returns the format of a tasks file: the forced one if set,
+// otherwise detected from its name with YAML as the default
+func fileFormat(path, forced string) (string, error) {
+	switch strings.ToLower(forced) {
+	case "":
+	case "yml", FormatYAML:
+		return FormatYAML, nil
+	case FormatJSON, FormatTOML, FormatCrontab:
+		return strings.ToLower(forced), nil
+	default:
+		return "", newErr("unknown tasks file format", forced, "supported: yaml, json, toml, crontab")
+	}
+
+	if isCrontab, _ := isCrontabPath(path); isCrontab {
+		return FormatCrontab, nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".toml":
+		return FormatTOML, nil
+	}
+	return FormatYAML, nil
+}
+
+// parseTaskData parses a tasks file, the same way in every adapter
+func parseTaskData(data []byte, path, format string) (taskFile, error) {
+	var file taskFile
+	var problems ValidationErrors
+
+	switch format {
+	case FormatCrontab:
+		// /etc/crontab and /etc/cron.d files have a user field
+		_, withUser := isCrontabPath(path)
+		file, problems = parseCrontab(data, path, withUser)
+	default:
+		parse, ok := nodeParsers[format]
+		if !ok {
+			return taskFile{}, newErr("unsupported tasks file format", format)
+		}
+		root, err := parse(data)
+		if err != nil {
+			return taskFile{}, ValidationErrors{syntaxProblem(path, data, err)}
+		}
+		file, problems = decodeTaskFile(root, path)
+	}
+
+	if len(problems) > 0 {
+		return file, problems
+	}
+	return file, nil
+}
+
+// syntaxError is a parse error at a byte offset of the input
+type syntaxError struct {
+	offset  int
+	message string
+}
+
+func (e *syntaxError) Error() string { return e.message }
+
+// syntaxProblem turns a parse error into a ValidationError with its line and column
+func syntaxProblem(path string, data []byte, err error) ValidationError {
+	problem := ValidationError{File: path, Message: err.Error()}
+	if se, ok := err.(*syntaxError); ok {
+		problem.Line, problem.Column = lineColumn(data, se.offset)
+	}
+	return problem
+}
+
+// lineColumn converts a byte offset to a 1 based line and column
+func lineColumn(data []byte, offset int) (line, column int) {
+	offset = min(offset, len(data))
+	line = 1 + strings.Count(string(data[:offset]), "\n")
+	column = offset - strings.LastIndexByte(string(data[:offset]), '\n')
+	return line, column
+}
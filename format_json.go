@@ -0,0 +1,195 @@
+package crontask
+
+import (
+	"encoding/json"
+)
+
+// parseJSONNodes parses a JSON document into nodes keeping the position of
+// every value. Numbers and booleans become scalars with their literal text.
+func parseJSONNodes(data []byte) (*node, error) {
+	p := &jsonParser{data: data}
+	p.skipSpace()
+	if p.pos == len(data) {
+		return nil, nil
+	}
+	n, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(data) {
+		return nil, p.errorf("unexpected content after the document")
+	}
+	return n, nil
+}
+
+type jsonParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *jsonParser) errorf(message string) error {
+	return &syntaxError{offset: p.pos, message: message}
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) newNode(kind nodeKind) *node {
+	line, column := lineColumn(p.data, p.pos)
+	return &node{kind: kind, line: line, column: column}
+}
+
+func (p *jsonParser) value() (*node, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of JSON")
+	}
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"':
+		n := p.newNode(scalarNode)
+		s, err := p.string()
+		n.value = s
+		return n, err
+	default:
+		return p.literal()
+	}
+}
+
+func (p *jsonParser) object() (*node, error) {
+	n := p.newNode(mappingNode)
+	p.pos++ // {
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == '}' {
+		p.pos++
+		return n, nil
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+			return nil, p.errorf("expected a quoted key")
+		}
+		key := p.newNode(scalarNode)
+		s, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		key.value = s
+
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return nil, p.errorf("expected ':' after key")
+		}
+		p.pos++
+		p.skipSpace()
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		n.pairs = append(n.pairs, nodePair{key: key, value: value})
+
+		if done, err := p.next('}'); done || err != nil {
+			return n, err
+		}
+	}
+}
+
+func (p *jsonParser) array() (*node, error) {
+	n := p.newNode(sequenceNode)
+	p.pos++ // [
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == ']' {
+		p.pos++
+		return n, nil
+	}
+	for {
+		p.skipSpace()
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		n.items = append(n.items, item)
+
+		if done, err := p.next(']'); done || err != nil {
+			return n, err
+		}
+	}
+}
+
+// next consumes the ',' between members or the closing character
+func (p *jsonParser) next(closing byte) (done bool, err error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return false, p.errorf("unexpected end of JSON")
+	}
+	switch p.data[p.pos] {
+	case ',':
+		p.pos++
+		return false, nil
+	case closing:
+		p.pos++
+		return true, nil
+	}
+	return false, p.errorf("expected ',' or '" + string(closing) + "'")
+}
+
+func (p *jsonParser) string() (string, error) {
+	start := p.pos
+	for p.pos++; p.pos < len(p.data); p.pos++ {
+		switch p.data[p.pos] {
+		case '\\':
+			p.pos++
+		case '\n':
+			return "", p.errorf("unterminated string")
+		case '"':
+			p.pos++
+			var s string
+			if err := json.Unmarshal(p.data[start:p.pos], &s); err != nil {
+				p.pos = start
+				return "", p.errorf("invalid string")
+			}
+			return s, nil
+		}
+	}
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+// literal reads a number, true, false or null
+func (p *jsonParser) literal() (*node, error) {
+	n := p.newNode(scalarNode)
+	start := p.pos
+	for p.pos < len(p.data) && !isJSONDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+	text := string(p.data[start:p.pos])
+	switch {
+	case text == "null":
+		n.isNull = true
+	case text == "true" || text == "false" || json.Valid([]byte(text)):
+		n.value = text
+	default:
+		p.pos = start
+		return nil, p.errorf("invalid value")
+	}
+	return n, nil
+}
+
+func isJSONDelimiter(c byte) bool {
+	switch c {
+	case ',', '}', ']', ':', ' ', '\t', '\r', '\n':
+		return true
+	}
+	return false
+}
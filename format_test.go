@@ -0,0 +1,125 @@
+package crontask
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var formatTestTasks = []Task{
+	{Name: "backup", Schedule: "0 1 * * *", Command: "/opt/backup.sh", Args: `--label "nightly"`,
+		OnFailure: []string{"notify"}, SuccessCodes: []int{0, 3}, FailOnStderr: true, Env: map[string]string{"PGHOST": "db1"}},
+	{Name: "notify", Command: "mail", Stdin: "backup failed\nsee the logs\n"},
+}
+
+var formatTestWorkflows = []Workflow{
+	{Name: "nightly", Schedule: "0 2 * * *", Steps: []WorkflowStep{{Task: "backup"}, {Task: "notify", DependsOn: []string{"backup"}}}},
+}
+
+func TestParseJSONTaskFile(t *testing.T) {
+	data := `{
+  "tasks": [
+    {"name": "backup", "schedule": "0 1 * * *", "command": "/opt/backup.sh", "args": "--label \"nightly\"",
+     "on_failure": ["notify"], "success_codes": [0, 3], "fail_on_stderr": true, "env": {"PGHOST": "db1"}},
+    {"name": "notify", "command": "mail", "stdin": "backup failed\nsee the logs\n", "user": null}
+  ],
+  "workflows": [
+    {"name": "nightly", "schedule": "0 2 * * *", "steps": [{"task": "backup"}, {"task": "notify", "depends_on": ["backup"]}]}
+  ]
+}`
+	file, err := parseTaskData([]byte(data), "tasks.json", FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFormatTestFile(t, file)
+
+	if p := file.positions["tasks[1].command"]; p.line != 5 || p.column != 35 {
+		t.Errorf("tasks[1].command position %d:%d, want 5:35", p.line, p.column)
+	}
+}
+
+func TestParseTOMLTaskFile(t *testing.T) {
+	data := `# Nightly jobs
+[[tasks]]
+name = "backup"
+schedule = "0 1 * * *"
+command = '/opt/backup.sh'
+args = '--label "nightly"'
+on_failure = [
+  "notify", # trailing comma and comments are fine
+]
+success_codes = [0, 3]
+fail_on_stderr = true
+env.PGHOST = "db1"
+
+[[tasks]]
+name = "notify"
+command = "mail"
+stdin = """
+backup failed
+see the logs
+"""
+
+[[workflows]]
+name = "nightly"
+schedule = "0 2 * * *"
+steps = [{task = "backup"}, {task = "notify", depends_on = ["backup"]}]
+`
+	file, err := parseTaskData([]byte(data), "tasks.toml", FormatTOML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFormatTestFile(t, file)
+
+	if p := file.positions["tasks[1].command"]; p.line != 16 || p.column != 11 {
+		t.Errorf("tasks[1].command position %d:%d, want 16:11", p.line, p.column)
+	}
+}
+
+func checkFormatTestFile(t *testing.T, file taskFile) {
+	t.Helper()
+	if !reflect.DeepEqual(file.Tasks, formatTestTasks) {
+		t.Errorf("tasks\n got %+v\nwant %+v", file.Tasks, formatTestTasks)
+	}
+	if !reflect.DeepEqual(file.Workflows, formatTestWorkflows) {
+		t.Errorf("workflows\n got %+v\nwant %+v", file.Workflows, formatTestWorkflows)
+	}
+}
+
+func TestTaskFileFormatErrors(t *testing.T) {
+	for _, tc := range []struct {
+		format, data string
+		want         string
+	}{
+		{FormatJSON, "[\n  {\"name\": \"a\",}\n]", "tasks.json:2:16: expected a quoted key"},
+		{FormatJSON, `[{"name": "a", "comand": "echo"}]`, `tasks.json:1:16: unknown key "comand", did you mean "command"?`},
+		{FormatTOML, "[[tasks]]\nname = backup\n", `tasks.toml:2:8: invalid value "backup", text must be quoted`},
+		{FormatTOML, "[[tasks]]\nname = \"a\"\nnice = \"high\"\n", "tasks.toml:3:8: nice: expected an integer"},
+		{FormatTOML, "[[tasks]]\nname = \"a\"\nname = \"b\"\n", `tasks.toml:3:1: duplicate key "name"`},
+		{FormatTOML, "[[tasks]]\nname = \"a\" command = \"echo\"\n", "tasks.toml:2:12: expected a new line"},
+	} {
+		_, err := parseTaskData([]byte(tc.data), "tasks."+tc.format, tc.format)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s %q: got error %v, want %s", tc.format, tc.data, err, tc.want)
+		}
+	}
+}
+
+func TestFileFormat(t *testing.T) {
+	for _, tc := range []struct{ path, forced, want string }{
+		{"crontasks.yml", "", FormatYAML},
+		{"tasks.JSON", "", FormatJSON},
+		{"/etc/crontask.d/backup.toml", "", FormatTOML},
+		{"/etc/crontab", "", FormatCrontab},
+		{"tasks.conf", "", FormatYAML},
+		{"tasks.conf", "json", FormatJSON},
+		{"tasks.yml", "YML", FormatYAML},
+	} {
+		if got, err := fileFormat(tc.path, tc.forced); err != nil || got != tc.want {
+			t.Errorf("fileFormat(%q, %q) = %q, %v want %q", tc.path, tc.forced, got, err, tc.want)
+		}
+	}
+	if _, err := fileFormat("tasks.ini", "ini"); err == nil {
+		t.Error("expected unknown format error")
+	}
+}
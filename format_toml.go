@@ -0,0 +1,429 @@
+package crontask
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOMLNodes parses a TOML document into nodes keeping the position of
+// every key and value. It covers what tasks files need: tables, arrays of
+// tables eg: [[tasks]], dotted and quoted keys, all the string kinds,
+// numbers, booleans, arrays and inline tables. Dates are kept as text.
+func parseTOMLNodes(data []byte) (*node, error) {
+	p := &tomlParser{data: data}
+	root := &node{kind: mappingNode, line: 1, column: 1}
+	table := root
+
+	for {
+		p.skipBlank()
+		if p.pos >= len(data) {
+			return root, nil
+		}
+
+		var err error
+		if data[p.pos] == '[' {
+			table, err = p.header(root)
+		} else {
+			err = p.keyValue(table)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Only a comment may follow on the same line
+		p.skipSpace()
+		p.skipComment()
+		if p.pos < len(data) && data[p.pos] != '\n' && data[p.pos] != '\r' {
+			return nil, p.errorf("expected a new line")
+		}
+	}
+}
+
+type tomlParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *tomlParser) errorf(message string) error {
+	return &syntaxError{offset: p.pos, message: message}
+}
+
+func (p *tomlParser) newNode(kind nodeKind) *node {
+	line, column := lineColumn(p.data, p.pos)
+	return &node{kind: kind, line: line, column: column}
+}
+
+func (p *tomlParser) peek(s string) bool {
+	return strings.HasPrefix(string(p.data[p.pos:]), s)
+}
+
+func (p *tomlParser) skipSpace() {
+	for p.pos < len(p.data) && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if p.pos < len(p.data) && p.data[p.pos] == '#' {
+		for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipBlank skips spaces, new lines and comments
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpace()
+		p.skipComment()
+		if p.pos < len(p.data) && (p.data[p.pos] == '\n' || p.data[p.pos] == '\r') {
+			p.pos++
+			continue
+		}
+		return
+	}
+}
+
+// header reads [table] or [[array.of.tables]] and returns the table that
+// receives the following keys
+func (p *tomlParser) header(root *node) (*node, error) {
+	offset := p.pos
+	start := p.newNode(mappingNode)
+	isArray := p.peek("[[")
+	p.pos++
+	if isArray {
+		p.pos++
+	}
+
+	p.skipSpace()
+	keys, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	closing := "]"
+	if isArray {
+		closing = "]]"
+	}
+	if !p.peek(closing) {
+		return nil, p.errorf("expected " + closing + " to close the table header")
+	}
+	p.pos += len(closing)
+
+	parent, err := p.walk(root, keys[:len(keys)-1], offset)
+	if err != nil {
+		return nil, err
+	}
+	last := keys[len(keys)-1]
+	existing := lookupPair(parent, last.value)
+
+	if isArray {
+		if existing == nil {
+			existing = &nodePair{key: last, value: &node{kind: sequenceNode, line: last.line, column: last.column}}
+			parent.pairs = append(parent.pairs, *existing)
+			existing = &parent.pairs[len(parent.pairs)-1]
+		}
+		if existing.value.kind != sequenceNode {
+			return nil, &syntaxError{offset: offset, message: "key " + strconv.Quote(last.value) + " is not an array of tables"}
+		}
+		existing.value.items = append(existing.value.items, start)
+		return start, nil
+	}
+
+	if existing != nil {
+		if existing.value.kind != mappingNode {
+			return nil, &syntaxError{offset: offset, message: "key " + strconv.Quote(last.value) + " is not a table"}
+		}
+		return existing.value, nil
+	}
+	parent.pairs = append(parent.pairs, nodePair{key: last, value: start})
+	return start, nil
+}
+
+// walk follows the keys of a header or dotted key from table, creating the
+// missing tables and entering the last element of arrays of tables
+func (p *tomlParser) walk(table *node, keys []*node, offset int) (*node, error) {
+	for _, k := range keys {
+		pair := lookupPair(table, k.value)
+		if pair == nil {
+			child := &node{kind: mappingNode, line: k.line, column: k.column}
+			table.pairs = append(table.pairs, nodePair{key: k, value: child})
+			table = child
+			continue
+		}
+		switch {
+		case pair.value.kind == mappingNode:
+			table = pair.value
+		case pair.value.kind == sequenceNode && len(pair.value.items) > 0 && pair.value.items[len(pair.value.items)-1].kind == mappingNode:
+			table = pair.value.items[len(pair.value.items)-1]
+		default:
+			return nil, &syntaxError{offset: offset, message: "key " + strconv.Quote(k.value) + " is not a table"}
+		}
+	}
+	return table, nil
+}
+
+func lookupPair(table *node, key string) *nodePair {
+	for i := range table.pairs {
+		if table.pairs[i].key.value == key {
+			return &table.pairs[i]
+		}
+	}
+	return nil
+}
+
+// keyValue reads key = value into table
+func (p *tomlParser) keyValue(table *node) error {
+	offset := p.pos
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.pos >= len(p.data) || p.data[p.pos] != '=' {
+		return p.errorf("expected '=' after key")
+	}
+	p.pos++
+	p.skipSpace()
+
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+	table, err = p.walk(table, keys[:len(keys)-1], offset)
+	if err != nil {
+		return err
+	}
+	// Repeated keys are left to the decoder, which reports them with their position
+	table.pairs = append(table.pairs, nodePair{key: keys[len(keys)-1], value: value})
+	return nil
+}
+
+// key reads a bare, quoted or dotted key eg: tasks."my task".env
+func (p *tomlParser) key() ([]*node, error) {
+	var keys []*node
+	for {
+		p.skipSpace()
+		k := p.newNode(scalarNode)
+		switch {
+		case p.pos >= len(p.data):
+			return nil, p.errorf("expected a key")
+		case p.data[p.pos] == '"' || p.data[p.pos] == '\'':
+			s, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			k.value = s
+		default:
+			start := p.pos
+			for p.pos < len(p.data) && isBareKeyChar(p.data[p.pos]) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("expected a key")
+			}
+			k.value = string(p.data[start:p.pos])
+		}
+		keys = append(keys, k)
+
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) value() (*node, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("expected a value")
+	}
+	switch p.data[p.pos] {
+	case '"', '\'':
+		n := p.newNode(scalarNode)
+		s, err := p.string()
+		n.value = s
+		return n, err
+	case '[':
+		return p.array()
+	case '{':
+		return p.inlineTable()
+	}
+
+	// Numbers, booleans and dates are kept as written, without the _ separators
+	n := p.newNode(scalarNode)
+	start := p.pos
+	for p.pos < len(p.data) && !strings.ContainsRune(",]}# \t\r\n", rune(p.data[p.pos])) {
+		p.pos++
+	}
+	text := string(p.data[start:p.pos])
+	if text == "" {
+		return nil, p.errorf("expected a value")
+	}
+	if text != "true" && text != "false" && !strings.ContainsAny(text[:1], "0123456789+-in") {
+		p.pos = start
+		return nil, p.errorf("invalid value " + strconv.Quote(text) + ", text must be quoted")
+	}
+	n.value = strings.ReplaceAll(text, "_", "")
+	return n, nil
+}
+
+func (p *tomlParser) array() (*node, error) {
+	n := p.newNode(sequenceNode)
+	p.pos++ // [
+	for {
+		p.skipBlank()
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			p.pos++
+			return n, nil
+		}
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		n.items = append(n.items, item)
+
+		p.skipBlank()
+		switch {
+		case p.pos < len(p.data) && p.data[p.pos] == ',':
+			p.pos++
+		case p.pos < len(p.data) && p.data[p.pos] == ']':
+		default:
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *tomlParser) inlineTable() (*node, error) {
+	n := p.newNode(mappingNode)
+	p.pos++ // {
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == '}' {
+		p.pos++
+		return n, nil
+	}
+	for {
+		if err := p.keyValue(n); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		switch {
+		case p.pos < len(p.data) && p.data[p.pos] == ',':
+			p.pos++
+		case p.pos < len(p.data) && p.data[p.pos] == '}':
+			p.pos++
+			return n, nil
+		default:
+			return nil, p.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+// string reads any of the four TOML strings, the basic and literal ones
+// and their multi-line versions:
+//
+//	"basic"  'literal'  """multi-line"""  '''multi-line'''
+func (p *tomlParser) string() (string, error) {
+	start := p.pos
+	quote := p.data[p.pos]
+	delimiter := string(quote)
+	multiline := p.peek(strings.Repeat(delimiter, 3))
+	if multiline {
+		delimiter = strings.Repeat(delimiter, 3)
+		p.pos += 3
+		// A new line right after the opening delimiter is trimmed
+		if p.peek("\r\n") {
+			p.pos += 2
+		} else if p.peek("\n") {
+			p.pos++
+		}
+	} else {
+		p.pos++
+	}
+
+	var out strings.Builder
+	for p.pos < len(p.data) {
+		if p.peek(delimiter) {
+			p.pos += len(delimiter)
+			// Up to two quotes right before the closing delimiter belong to the string
+			for extra := 0; multiline && extra < 2 && p.pos < len(p.data) && p.data[p.pos] == quote; extra++ {
+				out.WriteByte(quote)
+				p.pos++
+			}
+			return out.String(), nil
+		}
+
+		c := p.data[p.pos]
+		switch {
+		case c == '\n' && !multiline:
+			p.pos = start
+			return "", p.errorf("unterminated string")
+		case c == '\\' && quote == '"':
+			if err := p.escape(&out, multiline); err != nil {
+				return "", err
+			}
+		default:
+			out.WriteByte(c)
+			p.pos++
+		}
+	}
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+func (p *tomlParser) escape(out *strings.Builder, multiline bool) error {
+	p.pos++ // \
+	if p.pos >= len(p.data) {
+		return p.errorf("unterminated string")
+	}
+	c := p.data[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		out.WriteByte('\b')
+	case 't':
+		out.WriteByte('\t')
+	case 'n':
+		out.WriteByte('\n')
+	case 'f':
+		out.WriteByte('\f')
+	case 'r':
+		out.WriteByte('\r')
+	case 'e':
+		out.WriteByte(0x1b)
+	case '"', '\\':
+		out.WriteByte(c)
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.data) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(string(p.data[p.pos:p.pos+size]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape")
+		}
+		out.WriteRune(rune(code))
+		p.pos += size
+	case ' ', '\t', '\r', '\n':
+		// A line ending backslash trims the new line and the following whitespace
+		if !multiline {
+			return p.errorf("invalid escape")
+		}
+		p.pos--
+		for p.pos < len(p.data) && strings.IndexByte(" \t\r\n", p.data[p.pos]) >= 0 {
+			p.pos++
+		}
+	default:
+		p.pos -= 2
+		return p.errorf("invalid escape \\" + string(c))
+	}
+	return nil
+}
@@ -1,5 +1,3 @@
 module github.com/cdvelop/crontask
 
 go 1.22.0
-
-require github.com/goccy/go-yaml v1.17.1
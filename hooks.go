@@ -0,0 +1,97 @@
+package crontask
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Hooks are callbacks around every run of a task, the tasks of the tasks
+// file and the Go functions of AddTaskSchedule alike. They run on the
+// goroutine of the run, a slow hook delays the run and its follow-ups.
+type Hooks struct {
+	BeforeRun func(task Task) error                        // a non-nil error skips the run with the error as its reason eg: a lock already held
+	AfterRun  func(task Task, result RunResult)            // every finished run, skipped and failed ones included
+	OnError   func(task Task, result RunResult, err error) // failed runs
+	OnSkip    func(task Task, result RunResult)            // skipped runs eg: by BeforeRun, a skip code or a failed workflow dependency
+	OnPanic   func(task Task, recovered any, stack []byte) // a Go function, a middleware or BeforeRun panicked, the run is recorded as failed
+}
+
+// Runner executes a task and returns its result
+type Runner func(task Task) (RunResult, error)
+
+// Middleware wraps a Runner with code run before and after the next one
+// eg: timing, tracing or a lock. The first of Config.Middleware is the outermost.
+//
+//	func timing(next crontask.Runner) crontask.Runner {
+//		return func(task crontask.Task) (crontask.RunResult, error) {
+//			start := time.Now()
+//			defer func() { log.Println(task.Name, time.Since(start)) }()
+//			return next(task)
+//		}
+//	}
+type Middleware func(next Runner) Runner
+
+// execute runs the hooks and the middleware chain around the execution of a
+// task, a panic on the way becomes a failed run
+func (c *CronTaskEngine) execute(task Task) (result RunResult, err error) {
+	start := time.Now()
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		result = RunResult{Task: task.Name, Status: StatusFailed, ExitCode: -1, Start: start, Duration: time.Since(start), Reason: fmt.Sprint("panic: ", r)}
+		err = newErr(task.Name, "failed:", result.Reason)
+		if c.hooks.OnPanic != nil {
+			c.hooks.OnPanic(task, r, debug.Stack())
+		}
+	}()
+
+	if c.hooks.BeforeRun != nil {
+		if err := c.hooks.BeforeRun(task); err != nil {
+			return RunResult{Task: task.Name, Status: StatusSkipped, ExitCode: -1, Start: start, Reason: err.Error()}, nil
+		}
+	}
+
+	run := Runner(c.executeTask)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		run = c.middleware[i](run)
+	}
+	return run(task)
+}
+
+// executeTask calls the Go function of the task or runs its command through the adapter
+func (c *CronTaskEngine) executeTask(task Task) (RunResult, error) {
+	if task.fn == nil {
+		return c.adapter.ExecuteCmd(task)
+	}
+	result := RunResult{Task: task.Name, Status: StatusSuccess, Start: time.Now()}
+	task.fn()
+	result.Duration = time.Since(result.Start)
+	return result, nil
+}
+
+// finished calls the hooks of a finished run
+func (h Hooks) finished(task Task, result RunResult, err error) {
+	if h.AfterRun != nil {
+		h.AfterRun(task, result)
+	}
+	switch {
+	case result.Status == StatusFailed && h.OnError != nil:
+		h.OnError(task, result, err)
+	case result.Status == StatusSkipped && h.OnSkip != nil:
+		h.OnSkip(task, result)
+	}
+}
+
+// funcName is the name of a Go function eg: "main.backup", the task name of
+// its runs
+func funcName(fn any) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "func"
+}
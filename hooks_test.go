@@ -0,0 +1,117 @@
+package crontask
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	a := &fakeAdapter{exitCodes: map[string]int{"bad": 1}}
+	c := newFakeEngine(a, Task{Name: "ok"}, Task{Name: "bad"}, Task{Name: "locked"})
+
+	var calls []string
+	c.hooks = Hooks{
+		BeforeRun: func(task Task) error {
+			calls = append(calls, "before "+task.Name)
+			if task.Name == "locked" {
+				return errors.New("lock held")
+			}
+			return nil
+		},
+		AfterRun: func(task Task, result RunResult) {
+			calls = append(calls, "after "+task.Name+" "+string(result.Status))
+		},
+		OnError: func(task Task, result RunResult, err error) {
+			calls = append(calls, "error "+task.Name+" "+err.Error())
+		},
+		OnSkip: func(task Task, result RunResult) {
+			calls = append(calls, "skip "+task.Name+" "+result.Reason)
+		},
+	}
+
+	for _, name := range []string{"ok", "bad", "locked"} {
+		c.ExecuteTask(name)
+	}
+
+	want := []string{
+		"before ok", "after ok success",
+		"before bad", "after bad failed", "error bad bad failed: exit code 1",
+		"before locked", "after locked skipped", "skip locked lock held",
+	}
+	if !slices.Equal(calls, want) {
+		t.Errorf("calls\n%q\nwant\n%q", calls, want)
+	}
+	if names := a.names(); !slices.Equal(names, []string{"ok", "bad"}) {
+		t.Errorf("executed %v, the locked task must not run", names)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	a := &fakeAdapter{}
+	c := newFakeEngine(a, Task{Name: "report"})
+
+	var calls []string
+	wrap := func(name string) Middleware {
+		return func(next Runner) Runner {
+			return func(task Task) (RunResult, error) {
+				calls = append(calls, name+" in")
+				result, err := next(task)
+				calls = append(calls, name+" out")
+				result.Output += name
+				return result, err
+			}
+		}
+	}
+	c.middleware = []Middleware{wrap("outer"), wrap("inner")}
+
+	result, err := c.RunTask("report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"outer in", "inner in", "inner out", "outer out"}; !slices.Equal(calls, want) {
+		t.Errorf("calls %q, want %q", calls, want)
+	}
+	if result.Output != "innerouter" || c.History()[0].Output != "innerouter" {
+		t.Errorf("result output %q, history %q", result.Output, c.History()[0].Output)
+	}
+}
+
+func TestGoFunctionJob(t *testing.T) {
+	a := &fakeAdapter{}
+	c := newFakeEngine(a)
+
+	var ran []string
+	c.middleware = []Middleware{func(next Runner) Runner {
+		return func(task Task) (RunResult, error) {
+			ran = append(ran, task.Name)
+			return next(task)
+		}
+	}}
+	var panicked any
+	c.hooks.OnPanic = func(task Task, recovered any, stack []byte) { panicked = recovered }
+
+	if err := c.AddTaskSchedule("* * * * *", backupJob); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddTaskSchedule("* * * * *", func() { panic("disk full") }); err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range a.programs {
+		fn.(func())()
+	}
+
+	history := c.History()
+	if len(history) != 2 || len(ran) != 2 {
+		t.Fatalf("history %v, middleware saw %v", history, ran)
+	}
+	if !strings.HasSuffix(history[0].Task, ".backupJob") || history[0].Status != StatusSuccess {
+		t.Errorf("first run %+v", history[0])
+	}
+	if history[1].Status != StatusFailed || history[1].Reason != "panic: disk full" || panicked != "disk full" {
+		t.Errorf("panicking run %+v, OnPanic got %v", history[1], panicked)
+	}
+}
+
+func backupJob() {}
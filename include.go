@@ -0,0 +1,110 @@
+package crontask
+
+import (
+	"strconv"
+	"strings"
+)
+
+// taskFileReader reads the files behind a tasks path, implemented by each adapter
+type taskFileReader interface {
+	// expandTasksPath returns the files matched by a tasks path eg: a file,
+	// a directory "conf.d" or a glob "/etc/crontask.d/*.yml"
+	expandTasksPath(pattern string) ([]string, error)
+	// readTaskFile reads and parses a single tasks file in the given format.
+	// On ValidationErrors the returned file holds whatever could be decoded.
+	readTaskFile(path, format string) (taskFile, error)
+	// resolveInclude returns the location of an include: entry relative to the including file
+	resolveInclude(from, include string) string
+	// checkTask validates the fields only this adapter understands
+	checkTask(t Task) error
+}
+
+// loadTaskFiles reads every file matched by pattern, following their include:
+// entries, merges them into one task set, applies its defaults: and templates:
+// and validates it. All the problems
+// found in all the files are returned at once as ValidationErrors. Files
+// already read are not read again, so overlapping globs and include cycles
+// are harmless. A format other than "" is used for every file.
+func loadTaskFiles(pattern, format string, r taskFileReader) (taskFile, error) {
+	merged := taskFile{positions: make(map[string]position)}
+	var problems ValidationErrors
+	read := make(map[string]bool)
+
+	var load func(pattern string) error
+	load = func(pattern string) error {
+		paths, err := r.expandTasksPath(pattern)
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return newErr("no tasks files match", pattern)
+		}
+
+		for _, path := range paths {
+			if read[path] {
+				continue
+			}
+			read[path] = true
+			merged.sources = append(merged.sources, path)
+
+			// Keep going with what could be decoded to report every problem at once
+			pathFormat, err := fileFormat(path, format)
+			if err != nil {
+				return err
+			}
+			file, err := r.readTaskFile(path, pathFormat)
+			if fileProblems, ok := err.(ValidationErrors); ok {
+				problems = append(problems, fileProblems...)
+			} else if err != nil {
+				problems = append(problems, ValidationError{File: path, Message: err.Error()})
+				continue
+			}
+
+			problems = append(problems, mergeTemplates(&merged, file)...)
+			for key, p := range file.positions {
+				merged.positions[shiftPath(key, len(merged.Tasks), len(merged.Workflows))] = p
+			}
+			for _, t := range file.Tasks {
+				t.source = path
+				merged.Tasks = append(merged.Tasks, t)
+			}
+			merged.Workflows = append(merged.Workflows, file.Workflows...)
+			merged.secrets = append(merged.secrets, file.secrets...)
+
+			for _, include := range file.Include {
+				if err := load(r.resolveInclude(path, include)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := load(pattern); err != nil {
+		return taskFile{}, err
+	}
+
+	problems = append(problems, resolveTemplates(&merged)...)
+	problems = append(problems, validateTaskFile(merged, r.checkTask)...)
+	if err := problems.err(); err != nil {
+		return taskFile{}, err
+	}
+	return merged, nil
+}
+
+// shiftPath renumbers a position path of a single file to its place in the
+// merged task set eg: "tasks[1].schedule" with 3 tasks before => "tasks[4].schedule"
+func shiftPath(path string, tasksBefore, workflowsBefore int) string {
+	for prefix, offset := range map[string]int{"tasks[": tasksBefore, "workflows[": workflowsBefore} {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		end := strings.IndexByte(path, ']')
+		index, err := strconv.Atoi(path[len(prefix):end])
+		if err != nil {
+			return path
+		}
+		return prefix + strconv.Itoa(index+offset) + path[end:]
+	}
+	return path
+}
@@ -0,0 +1,250 @@
+//go:build !wasm
+
+package crontask
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTaskFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func taskNames(tasks []Task) []string {
+	var names []string
+	for _, t := range tasks {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func TestLoadTasksDirectoryAndIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFiles(t, dir, map[string]string{
+		"crontask.d/10-backup.yml": `- name: "backup"
+  schedule: "0 1 * * *"
+  command: "echo"
+`,
+		"crontask.d/20-reports.yaml": `include: ["../shared/*.yml"]
+tasks:
+  - name: "reports"
+    schedule: "0 6 * * *"
+    command: "echo"
+`,
+		"crontask.d/notes.txt": "not a task file",
+		"shared/cleanup.yml": `- name: "cleanup"
+  schedule: "0 3 * * *"
+  command: "echo"
+`,
+	})
+	a := &nativeAdapter{}
+
+	file, err := a.GetTasksFromPath(filepath.Join(dir, "crontask.d"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := taskNames(file.Tasks), []string{"backup", "reports", "cleanup"}; !slices.Equal(got, want) {
+		t.Errorf("directory tasks %v, want %v", got, want)
+	}
+	if file.Tasks[2].source != filepath.Join(dir, "shared", "cleanup.yml") {
+		t.Errorf("included task source = %q", file.Tasks[2].source)
+	}
+
+	file, err = a.GetTasksFromPath(filepath.Join(dir, "crontask.d", "*.yml"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := taskNames(file.Tasks), []string{"backup"}; !slices.Equal(got, want) {
+		t.Errorf("glob tasks %v, want %v", got, want)
+	}
+
+	if _, err := a.GetTasksFromPath(filepath.Join(dir, "missing", "*.yml"), ""); err == nil {
+		t.Error("a glob without matches should be an error")
+	}
+}
+
+func TestLoadTasksDuplicateNames(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFiles(t, dir, map[string]string{
+		"team_a.yml": `- name: "backup"
+  schedule: "0 1 * * *"
+  command: "echo"
+`,
+		"team_b.yml": `- name: "backup"
+  schedule: "0 2 * * *"
+  command: "echo"
+`,
+	})
+	a := &nativeAdapter{}
+
+	_, err := a.GetTasksFromPath(dir, "")
+	if err == nil {
+		t.Fatal("duplicate task names should be rejected")
+	}
+	for _, file := range []string{"team_a.yml", "team_b.yml"} {
+		if !strings.Contains(err.Error(), file) {
+			t.Errorf("error %q should name both files, missing %s", err, file)
+		}
+	}
+}
+
+func TestPollFilesDetectsNewFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFiles(t, dir, map[string]string{"a.yml": "- name: a\n"})
+
+	quit := make(chan struct{})
+	defer close(quit)
+	changes, err := pollFiles(newWatchTargets(dir, []string{filepath.Join(dir, "a.yml")}), 10*time.Millisecond, quit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTaskFiles(t, dir, map[string]string{"notes.txt": "ignored"})
+	select {
+	case <-changes:
+		t.Fatal("files outside the task set should not report changes")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	writeTaskFiles(t, dir, map[string]string{"b.yml": "- name: b\n"})
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("new file in the tasks directory not detected")
+	}
+}
+
+func TestWatchIncludeAddedAfterStartup(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFiles(t, dir, map[string]string{
+		"crontasks.yml": `tasks:
+  - name: "first"
+    command: "echo"
+`,
+		"shared/extra.yml": `- name: "extra"
+  command: "echo"
+`,
+	})
+	c := NewCronTaskEngine(Config{
+		TasksPath:      filepath.Join(dir, "crontasks.yml"),
+		WatchTasksFile: true,
+		WatchInterval:  50 * time.Millisecond,
+		Logger:         discardLogger,
+	})
+	defer close(c.quit)
+
+	waitTasks := func(want int, why string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for len(c.GetTasks()) != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("%s, tasks: %v", why, c.GetTasks())
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	writeTaskFiles(t, dir, map[string]string{
+		"crontasks.yml": `include: ["shared/extra.yml"]
+tasks:
+  - name: "first"
+    command: "echo"
+`,
+	})
+	waitTasks(2, "include not loaded")
+
+	// The included file is watched from the reload that added it
+	writeTaskFiles(t, dir, map[string]string{
+		"shared/extra.yml": `- name: "extra"
+  command: "echo"
+- name: "more"
+  command: "echo"
+`,
+	})
+	waitTasks(3, "change of the new include not reloaded")
+}
+
+func TestLoadCrontabFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFiles(t, dir, map[string]string{
+		"cron.d/backup":       "# Backup\n0 1 * * * root /opt/backup.sh\n",
+		"cron.d/.placeholder": "",
+		"cron.d/php":          "@hourly www-data php /var/www/cron.php\n",
+		"user.crontab":        "*/5 * * * * /opt/poll.sh $HOME\n",
+	})
+	a := &nativeAdapter{}
+
+	file, err := a.GetTasksFromPath(filepath.Join(dir, "cron.d"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(file.Tasks); !slices.Equal(got, []string{"Backup", "line 1"}) {
+		t.Errorf("cron.d tasks %v", got)
+	}
+	if file.Tasks[0].User != "root" || file.Tasks[1].User != "www-data" {
+		t.Errorf("cron.d files have a user field, got %q and %q", file.Tasks[0].User, file.Tasks[1].User)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "user.crontab"))
+	yml, err := ConvertCrontab(data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTaskFiles(t, dir, map[string]string{"converted.yml": string(yml)})
+
+	crontab, err := a.GetTasksFromPath(filepath.Join(dir, "user.crontab"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted, err := a.GetTasksFromPath(filepath.Join(dir, "converted.yml"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crontab.Tasks[0].source, converted.Tasks[0].source = "", ""
+	if !reflect.DeepEqual(crontab.Tasks, converted.Tasks) {
+		t.Errorf("converted file differs from the crontab\n got %+v\nwant %+v", converted.Tasks, crontab.Tasks)
+	}
+}
+
+func TestLoadMixedFormats(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFiles(t, dir, map[string]string{
+		"crontask.d/10-backup.yml":  "- name: \"backup\"\n  command: \"echo\"\n",
+		"crontask.d/20-deploy.json": `{"include": ["../reports.conf"], "tasks": [{"name": "deploy", "command": "echo"}]}`,
+		"crontask.d/30-clean.toml":  "[[tasks]]\nname = \"clean\"\ncommand = \"echo\"\n",
+		"reports.conf":              "- name: \"reports\"\n  command: \"echo\"\n",
+	})
+	a := &nativeAdapter{}
+
+	file, err := a.GetTasksFromPath(filepath.Join(dir, "crontask.d"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(file.Tasks); !slices.Equal(got, []string{"backup", "deploy", "reports", "clean"}) {
+		t.Errorf("loaded %v", got)
+	}
+
+	// A forced format applies to every file, whatever its name
+	writeTaskFiles(t, dir, map[string]string{"tasks.conf": `[{"name": "json", "command": "echo"}]`})
+	file, err = a.GetTasksFromPath(filepath.Join(dir, "tasks.conf"), FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(file.Tasks); !slices.Equal(got, []string{"json"}) {
+		t.Errorf("loaded %v", got)
+	}
+}
@@ -0,0 +1,201 @@
+package crontask
+
+import (
+	"os"
+	"strings"
+)
+
+// secretMask replaces the secret values of a task in logs and history
+const secretMask = "******"
+
+// minSecretLength keeps short values like "1" or "true" from being masked
+// inside unrelated text, a real secret is longer
+const minSecretLength = 6
+
+// runVarPrefix names the variables the engine sets for each run eg:
+// CRONTASK_PREV_EXIT, they are left for the command to expand
+const runVarPrefix = "CRONTASK_"
+
+// interpolate expands the variables of a tasks file value when it is loaded:
+//
+//	$VAR, ${VAR}             environment variable, empty when unset
+//	${VAR:-default}          default when VAR is unset or empty, ${VAR-default} only when unset
+//	${VAR:?message}          error when VAR is unset or empty, ${VAR?message} only when unset
+//	${file:/run/secrets/db}  content of a file without its trailing new line, a secret
+//	${secret:VAR}            environment variable that must be set, a secret
+//	$$                       a literal $
+//
+// A $ not followed by a name or { is kept as is eg: success_pattern: "^OK$",
+// and so are the variables of the CRONTASK_ namespace, which only exist
+// when the task runs eg: args: "-c 'echo $CRONTASK_PREV_EXIT'".
+// The values of ${file:...} and ${secret:...} are returned to be masked.
+func interpolate(s string) (value string, secrets []string, err error) {
+	if !strings.Contains(s, "$") {
+		return s, nil, nil
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+
+		switch next := s[i+1]; {
+		case next == '$':
+			out.WriteByte('$')
+			i++
+
+		case next == '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				return "", nil, newErr("missing } in", s[i:])
+			}
+			if strings.HasPrefix(s[i+2:], runVarPrefix) {
+				out.WriteString(s[i : end+1])
+				i = end
+				continue
+			}
+			expanded, exprSecrets, err := expandExpr(s[i+2 : end])
+			if err != nil {
+				return "", nil, err
+			}
+			out.WriteString(expanded)
+			secrets = append(secrets, exprSecrets...)
+			i = end
+
+		case isNameStart(next):
+			end := i + 1
+			for end < len(s) && isNameChar(s[end]) {
+				end++
+			}
+			if strings.HasPrefix(s[i+1:end], runVarPrefix) {
+				out.WriteString(s[i:end])
+				i = end - 1
+				continue
+			}
+			out.WriteString(os.Getenv(s[i+1 : end]))
+			i = end - 1
+
+		default:
+			out.WriteByte('$')
+		}
+	}
+	return out.String(), secrets, nil
+}
+
+// closingBrace returns the index of the } closing the ${ opened before start
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// expandExpr expands the content of a ${...} expression
+func expandExpr(expr string) (string, []string, error) {
+	if path, ok := strings.CutPrefix(expr, "file:"); ok {
+		path, secrets, err := interpolate(path)
+		if err != nil {
+			return "", nil, err
+		}
+		if path == "" {
+			return "", nil, newErr("missing file path in ${file:}")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, newErr("secret file", err)
+		}
+		secret := strings.TrimRight(string(data), "\r\n")
+		return secret, append(secrets, secret), nil
+	}
+
+	if name, ok := strings.CutPrefix(expr, "secret:"); ok {
+		valid := name != "" && isNameStart(name[0])
+		for i := 0; i < len(name); i++ {
+			valid = valid && isNameChar(name[i])
+		}
+		if !valid {
+			return "", nil, newErr("invalid variable ${" + expr + "}")
+		}
+		secret := os.Getenv(name)
+		if secret == "" {
+			return "", nil, newErr("secret variable", name, "not set")
+		}
+		return secret, []string{secret}, nil
+	}
+
+	end := 0
+	for end < len(expr) && isNameChar(expr[end]) {
+		end++
+	}
+	name, operator := expr[:end], expr[end:]
+	if name == "" || !isNameStart(name[0]) {
+		return "", nil, newErr("invalid variable ${" + expr + "}")
+	}
+	value, set := os.LookupEnv(name)
+
+	for _, op := range []string{":-", "-", ":?", "?"} {
+		word, ok := strings.CutPrefix(operator, op)
+		if !ok {
+			continue
+		}
+		// The colon forms treat an empty variable as unset
+		missing := !set || (value == "" && op[0] == ':')
+		if !missing {
+			return value, nil, nil
+		}
+		if strings.HasSuffix(op, "?") {
+			if word == "" {
+				word = "not set"
+			}
+			return "", nil, newErr("variable", name, word)
+		}
+		return interpolate(word)
+	}
+
+	if operator != "" {
+		return "", nil, newErr("invalid variable ${" + expr + "}")
+	}
+	return value, nil, nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9'
+}
+
+// setSecrets replaces the secrets masked in the engine logs
+func (c *CronTaskEngine) setSecrets(secrets []string) {
+	c.secretsMu.Lock()
+	defer c.secretsMu.Unlock()
+	c.secrets = secrets
+}
+
+// currentSecrets returns the secret values of the loaded tasks
+func (c *CronTaskEngine) currentSecrets() []string {
+	c.secretsMu.RLock()
+	defer c.secretsMu.RUnlock()
+	return c.secrets
+}
+
+// maskSecrets hides every secret of at least minSecretLength found in s
+func maskSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			s = strings.ReplaceAll(s, secret, secretMask)
+		}
+	}
+	return s
+}
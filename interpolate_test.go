@@ -0,0 +1,147 @@
+package crontask
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("CT_HOST", "db1")
+	t.Setenv("CT_EMPTY", "")
+	secretFile := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(secretFile, []byte("s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		in, want, secret string
+	}{
+		{"plain text", "plain text", ""},
+		{"-h $CT_HOST:5432", "-h db1:5432", ""},
+		{"-h ${CT_HOST}", "-h db1", ""},
+		{"${CT_MISSING}", "", ""},
+		{"${CT_MISSING:-localhost}", "localhost", ""},
+		{"${CT_EMPTY:-localhost}", "localhost", ""},
+		{"${CT_EMPTY-localhost}", "", ""},
+		{"${CT_MISSING:-${CT_HOST}}", "db1", ""},
+		{"--password=${file:" + secretFile + "}", "--password=s3cr3t", "s3cr3t"},
+		{"cost $$5, ^OK$ and $1", "cost $5, ^OK$ and $1", ""},
+		{"-c 'echo $CRONTASK_PREV_EXIT ${CRONTASK_PREV_TASK:-none}'", "-c 'echo $CRONTASK_PREV_EXIT ${CRONTASK_PREV_TASK:-none}'", ""},
+	} {
+		got, secrets, err := interpolate(tc.in)
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q: got %q want %q", tc.in, got, tc.want)
+		}
+		if tc.secret != "" && !slices.Equal(secrets, []string{tc.secret}) {
+			t.Errorf("%q: got secrets %q want %q", tc.in, secrets, tc.secret)
+		}
+	}
+
+	for _, in := range []string{"${CT_MISSING:?set the host}", "${CT_HOST", "${file:/nonexistent/secret}", "${1X}"} {
+		if _, _, err := interpolate(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestInterpolateTaskFile(t *testing.T) {
+	t.Setenv("CT_NICE", "5")
+	secretFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secretFile, []byte("tok-123"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	data := `- name: "report"
+  command: "curl"
+  args: "-H 'Authorization: Bearer ${file:` + secretFile + `}' ${CT_URL:-http://localhost}"
+  nice: ${CT_NICE}
+- name: "other"
+  command: "${CT_COMMAND:?missing command}"
+`
+	file, err := parseTaskData([]byte(data), "tasks.yml", FormatYAML)
+	if err == nil || !strings.Contains(err.Error(), "tasks.yml:6:12: command: variable CT_COMMAND missing command") {
+		t.Fatalf("expected the unset variable reported at its position, got %v", err)
+	}
+
+	report := file.Tasks[0]
+	if report.Args != "-H 'Authorization: Bearer tok-123' http://localhost" || report.Nice != 5 {
+		t.Errorf("interpolated task %+v", report)
+	}
+	if !slices.Equal(report.secrets, []string{"tok-123"}) || !slices.Equal(file.secrets, []string{"tok-123"}) {
+		t.Errorf("secrets %q, file secrets %q", report.secrets, file.secrets)
+	}
+}
+
+func TestOnlySecretsAreMasked(t *testing.T) {
+	t.Setenv("CT_PG_PASSWORD", "pg-pass-1")
+	t.Setenv("CT_DB_TOKEN", "db-token-2")
+	t.Setenv("CT_DEBUG_LEVEL", "1")
+
+	data := `defaults:
+  env: {DB_TOKEN: "${secret:CT_DB_TOKEN}"}
+tasks:
+  - name: "backup"
+    command: "pg_dump"
+    env:
+      PGPASSWORD: "${secret:CT_PG_PASSWORD}"
+      DEBUG: "${CT_DEBUG_LEVEL}"
+`
+	file, err := parseTaskData([]byte(data), "tasks.yml", FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Plain env: values are not secrets, ${secret:...} ones are
+	backup := file.Tasks[0]
+	if backup.Env["DEBUG"] != "1" || backup.Env["PGPASSWORD"] != "pg-pass-1" {
+		t.Errorf("env %v", backup.Env)
+	}
+	if !slices.Equal(backup.secrets, []string{"pg-pass-1"}) || !slices.Equal(file.Defaults.secrets, []string{"db-token-2"}) {
+		t.Errorf("task secrets %q, defaults secrets %q", backup.secrets, file.Defaults.secrets)
+	}
+	if got := maskSecrets("1 2 11 100 pg-pass-1", backup.secrets); got != "1 2 11 100 ******" {
+		t.Errorf("masked %q", got)
+	}
+
+	// Too short to be told apart from other text
+	if got := maskSecrets("debug: true 1", []string{"true", "1"}); got != "debug: true 1" {
+		t.Errorf("short values masked: %q", got)
+	}
+
+	for _, in := range []string{"${secret:CT_MISSING}", "${secret:}", "${secret:1X}"} {
+		if _, _, err := interpolate(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestMaskSecrets(t *testing.T) {
+	var logged bytes.Buffer
+	a := &fakeAdapter{exitCodes: map[string]int{"report": 1}, outputs: map[string]string{"report": "401 for token tok-123"}}
+	c := newFakeEngine(a, Task{Name: "report", Command: "curl", secrets: []string{"tok-123"}})
+	c.logger = slog.New(secretsHandler{slog.NewTextHandler(&logged, nil), c})
+	c.setSecrets([]string{"tok-123"})
+
+	c.ExecuteTask("report")
+	c.logger.With("token", "tok-123").Info("loaded token tok-123", "error", errors.New("bad token tok-123"))
+
+	if out := c.History()[0].Output; out != "401 for token ******" {
+		t.Errorf("history output %q", out)
+	}
+	if !strings.Contains(logged.String(), "task failed") {
+		t.Errorf("failed run not logged:\n%s", logged.String())
+	}
+	if strings.Contains(logged.String(), "tok-123") {
+		t.Errorf("secret logged:\n%s", logged.String())
+	}
+}
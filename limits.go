@@ -0,0 +1,134 @@
+package crontask
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// I/O scheduling classes accepted by ionice:, same values as ionice(1)
+const (
+	ioClassNone       = 0
+	ioClassRealtime   = 1
+	ioClassBestEffort = 2
+	ioClassIdle       = 3
+)
+
+// resourceLimits are the parsed nice:, ionice: and max_* fields of a task
+type resourceLimits struct {
+	nice      int
+	ioClass   int
+	ioLevel   int
+	memory    uint64        // bytes
+	cpuTime   time.Duration // rounded up to seconds when applied
+	openFiles uint64
+}
+
+func (l resourceLimits) isSet() bool {
+	return l != resourceLimits{}
+}
+
+// resourceLimits parses and validates the resource limit fields of a task
+func (t Task) resourceLimits() (resourceLimits, error) {
+	var l resourceLimits
+	var err error
+
+	if t.Nice < -20 || t.Nice > 19 {
+		return l, newErr("task", t.Name, "nice must be in range -20 - 19, got", t.Nice)
+	}
+	l.nice = t.Nice
+
+	if t.IONice != "" {
+		if l.ioClass, l.ioLevel, err = parseIONice(t.IONice); err != nil {
+			return l, newErr("task", t.Name, "invalid ionice", t.IONice+":", err)
+		}
+	}
+
+	if t.MaxMemory != "" {
+		if l.memory, err = parseSize(t.MaxMemory); err != nil {
+			return l, newErr("task", t.Name, "invalid max_memory", t.MaxMemory+":", err)
+		}
+	}
+
+	if t.MaxCPUTime != "" {
+		if l.cpuTime, err = time.ParseDuration(t.MaxCPUTime); err != nil || l.cpuTime <= 0 {
+			return l, newErr("task", t.Name, "invalid max_cpu_time", t.MaxCPUTime, "eg: \"30s\" or \"10m\"")
+		}
+	}
+
+	if t.MaxOpenFiles < 0 {
+		return l, newErr("task", t.Name, "max_open_files must be positive, got", t.MaxOpenFiles)
+	}
+	l.openFiles = uint64(t.MaxOpenFiles)
+
+	return l, nil
+}
+
+// checkLimits validates the resource limit fields at load time
+func checkLimits(t Task) error {
+	l, err := t.resourceLimits()
+	if err != nil {
+		return err
+	}
+	if l.isSet() && !limitsSupported {
+		return newErr("task", t.Name, "resource limits are only supported on linux")
+	}
+	return nil
+}
+
+// parseIONice parses "idle", "best-effort:7" or "realtime:0"
+func parseIONice(s string) (class, level int, err error) {
+	name, levelText, hasLevel := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+	switch name {
+	case "idle":
+		if hasLevel {
+			return 0, 0, newErr("idle class takes no level")
+		}
+		return ioClassIdle, 0, nil
+	case "best-effort", "be":
+		class = ioClassBestEffort
+		level = 4
+	case "realtime", "rt":
+		class = ioClassRealtime
+		level = 4
+	default:
+		return 0, 0, newErr("class must be idle, best-effort or realtime")
+	}
+
+	if hasLevel {
+		level, err = strconv.Atoi(levelText)
+		if err != nil || level < 0 || level > 7 {
+			return 0, 0, newErr("level must be in range 0 - 7")
+		}
+	}
+	return class, level, nil
+}
+
+// parseSize parses a byte size like "512M", "2G", "1024K" or "1048576"
+func parseSize(s string) (uint64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+
+	multiplier := uint64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			s = s[:n-1]
+		}
+	}
+
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || n == 0 {
+		return 0, newErr("size must be a positive number with an optional K, M, G or T suffix")
+	}
+	return n * multiplier, nil
+}
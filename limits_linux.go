@@ -0,0 +1,247 @@
+//go:build linux
+
+package crontask
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const limitsSupported = true
+
+// ioprio_set(2) constants, not exported by the syscall package
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+)
+
+// limitsEnv passes the limits of a task to the wrapper process, see startWithLimits
+const limitsEnv = "CRONTASK_EXEC_LIMITS"
+
+// limitsReportFd is the pipe where the wrapper writes why it could not exec the command
+const limitsReportFd = 3
+
+type rlimit64 struct {
+	cur uint64
+	max uint64
+}
+
+// limitsWrapper is set by RunLimitsWrapper: the executable can run the tasks
+// that have limits, /proc/self/exe will act as the wrapper
+var limitsWrapper bool
+
+// RunLimitsWrapper must be the first call of main in a program that runs
+// tasks with nice:, ionice: or max_* fields. Those tasks start through a
+// copy of the program itself, in that copy RunLimitsWrapper sets the limits
+// and replaces the process with the task command, it never returns. In any
+// other case it returns at once. Package init functions and the variables of
+// main run before it, they must not have side effects. Without this call the
+// tasks with limits fail to start.
+func RunLimitsWrapper() {
+	limitsWrapper = true
+	if spec, ok := os.LookupEnv(limitsEnv); ok {
+		execWithLimits(spec)
+	}
+}
+
+// startWithLimits starts cmd with the task limits in place from its first
+// instruction. A process can't set the rlimits of a child before exec, so
+// cmd runs through a copy of the current executable (/proc/self/exe) that
+// sets the limits on itself, switches to the task user and execs the command.
+// A failure before the exec comes back on a pipe that a successful exec closes.
+func startWithLimits(cmd *exec.Cmd, l resourceLimits) error {
+	if !l.isSet() || cmd.Err != nil {
+		return cmd.Start()
+	}
+	if !limitsWrapper {
+		return newErr("resource limits need crontask.RunLimitsWrapper() at the start of main")
+	}
+
+	spec := []string{
+		strconv.Itoa(l.nice),
+		strconv.Itoa(l.ioClass),
+		strconv.Itoa(l.ioLevel),
+		strconv.FormatUint(l.memory, 10),
+		strconv.FormatUint(uint64((l.cpuTime+999_999_999)/1_000_000_000), 10), // rounded up to seconds
+		strconv.FormatUint(l.openFiles, 10),
+	}
+	// The wrapper switches user itself: negative nice needs root privileges
+	if attr := cmd.SysProcAttr; attr != nil && attr.Credential != nil {
+		groups := make([]string, len(attr.Credential.Groups))
+		for i, g := range attr.Credential.Groups {
+			groups[i] = strconv.FormatUint(uint64(g), 10)
+		}
+		spec = append(spec,
+			strconv.FormatUint(uint64(attr.Credential.Uid), 10),
+			strconv.FormatUint(uint64(attr.Credential.Gid), 10),
+			strings.Join(groups, ","))
+		attr.Credential = nil
+	}
+
+	report, reportW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer report.Close()
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, limitsEnv+"="+strings.Join(spec, " "))
+	cmd.Args = append([]string{"crontask-limits", cmd.Path}, cmd.Args...)
+	cmd.Path = "/proc/self/exe"
+	cmd.ExtraFiles = []*os.File{reportW}
+
+	err = cmd.Start()
+	reportW.Close()
+	if err != nil {
+		return err
+	}
+
+	var message strings.Builder
+	buf := make([]byte, 512)
+	for {
+		n, err := report.Read(buf)
+		message.Write(buf[:n])
+		if err != nil {
+			break
+		}
+	}
+	if message.Len() > 0 {
+		cmd.Wait()
+		return newErr(message.String())
+	}
+	return nil
+}
+
+// execWithLimits is the wrapper of startWithLimits: it sets the limits
+// described by spec on its own process then execs os.Args[1] with the
+// arguments os.Args[2:]. The command doesn't inherit limitsEnv. It never returns.
+func execWithLimits(spec string) {
+	fail := func(what string, err error) {
+		syscall.Write(limitsReportFd, []byte(what+": "+err.Error()))
+		os.Exit(127)
+	}
+	// nice and ionice apply to the calling thread, the one that must exec
+	runtime.LockOSThread()
+	syscall.CloseOnExec(limitsReportFd)
+	os.Unsetenv(limitsEnv)
+
+	fields := strings.Fields(spec)
+	if len(fields) != 6 && len(fields) != 8 && len(fields) != 9 || len(os.Args) < 3 {
+		fail("limits", syscall.EINVAL)
+	}
+	var l resourceLimits
+	var cpuSecs uint64
+	l.nice, _ = strconv.Atoi(fields[0])
+	l.ioClass, _ = strconv.Atoi(fields[1])
+	l.ioLevel, _ = strconv.Atoi(fields[2])
+	l.memory, _ = strconv.ParseUint(fields[3], 10, 64)
+	cpuSecs, _ = strconv.ParseUint(fields[4], 10, 64)
+	l.openFiles, _ = strconv.ParseUint(fields[5], 10, 64)
+
+	// Everything execve needs is allocated before max_memory applies
+	path, err := syscall.BytePtrFromString(os.Args[1])
+	if err != nil {
+		fail("exec", err)
+	}
+	argv, err := syscall.SlicePtrFromStrings(os.Args[2:])
+	if err != nil {
+		fail("exec", err)
+	}
+	envv, err := syscall.SlicePtrFromStrings(os.Environ())
+	if err != nil {
+		fail("exec", err)
+	}
+
+	// Priorities first, raising them needs the privileges dropped below
+	if l.nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, 0, l.nice); err != nil {
+			fail("nice", err)
+		}
+	}
+	if l.ioClass != ioClassNone {
+		prio := l.ioClass<<ioprioClassShift | l.ioLevel
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(prio)); errno != 0 {
+			fail("ionice", errno)
+		}
+	}
+
+	if len(fields) > 6 {
+		uid, _ := strconv.Atoi(fields[6])
+		gid, _ := strconv.Atoi(fields[7])
+		var groups []int
+		if len(fields) > 8 {
+			for _, g := range strings.Split(fields[8], ",") {
+				id, _ := strconv.Atoi(g)
+				groups = append(groups, id)
+			}
+		}
+		if err := syscall.Setgroups(groups); err != nil {
+			fail("groups", err)
+		}
+		if err := syscall.Setgid(gid); err != nil {
+			fail("group", err)
+		}
+		if err := syscall.Setuid(uid); err != nil {
+			fail("user", err)
+		}
+	}
+
+	if l.memory > 0 {
+		if err := prlimit(0, syscall.RLIMIT_AS, rlimit64{l.memory, l.memory}); err != nil {
+			fail("max_memory", err)
+		}
+	}
+	if cpuSecs > 0 {
+		// SIGXCPU at the soft limit, SIGKILL one second later
+		if err := prlimit(0, syscall.RLIMIT_CPU, rlimit64{cpuSecs, cpuSecs + 1}); err != nil {
+			fail("max_cpu_time", err)
+		}
+	}
+	if l.openFiles > 0 {
+		if err := prlimit(0, syscall.RLIMIT_NOFILE, rlimit64{l.openFiles, l.openFiles}); err != nil {
+			fail("max_open_files", err)
+		}
+	}
+
+	_, _, errno := syscall.RawSyscall(syscall.SYS_EXECVE, uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(&argv[0])), uintptr(unsafe.Pointer(&envv[0])))
+	fail("exec "+os.Args[1], errno)
+}
+
+func prlimit(pid, resource int, lim rlimit64) error {
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource), uintptr(unsafe.Pointer(&lim)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// limitExceeded explains a process death caused by one of the task limits,
+// or returns "" when the process was not killed by a limit. Going over
+// max_memory makes an allocation fail, which most programs turn into one of
+// these signals: the cause is inferred, the kernel doesn't report it.
+func limitExceeded(state *os.ProcessState, l resourceLimits) string {
+	if state == nil {
+		return ""
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+
+	sig := status.Signal()
+	used := state.UserTime() + state.SystemTime()
+	switch {
+	case l.cpuTime > 0 && (sig == syscall.SIGXCPU || (sig == syscall.SIGKILL && used >= l.cpuTime)):
+		return "max_cpu_time limit exceeded (killed by " + sig.String() + ")"
+	case l.memory > 0 && (sig == syscall.SIGSEGV || sig == syscall.SIGABRT || sig == syscall.SIGBUS):
+		return "killed by " + sig.String() + ", inferred cause: max_memory limit reached"
+	}
+	return ""
+}
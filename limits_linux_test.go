@@ -0,0 +1,131 @@
+//go:build linux
+
+package crontask
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMaxCPUTimeKillsTask(t *testing.T) {
+	a := &nativeAdapter{}
+
+	result, err := a.ExecuteCmd(Task{
+		Name:       "busy_loop",
+		Command:    "sh",
+		Args:       "-c 'while :; do :; done'",
+		MaxCPUTime: "1s",
+		Nice:       5,
+	})
+	if err == nil {
+		t.Fatal("busy loop should have been killed by max_cpu_time")
+	}
+	if result.Status != StatusFailed || !strings.Contains(result.Reason, "max_cpu_time") {
+		t.Errorf("got status %s reason %q, want a max_cpu_time failure", result.Status, result.Reason)
+	}
+}
+
+func TestLimitsApplyBeforeExec(t *testing.T) {
+	a := &nativeAdapter{}
+
+	// The shell reads its own limits: they must be in place at exec
+	result, err := a.ExecuteCmd(Task{
+		Name:         "limits",
+		Command:      "sh",
+		Args:         "-c 'ulimit -n; ulimit -v; cut -d \" \" -f 19 /proc/self/stat'",
+		MaxOpenFiles: 64,
+		MaxMemory:    "512M",
+		Nice:         7,
+	})
+	if err != nil {
+		t.Fatal(err, result.Output)
+	}
+	if got := strings.Fields(result.Output); strings.Join(got, " ") != "64 524288 7" {
+		t.Errorf("limits seen by the command %q, want 64 524288 7", result.Output)
+	}
+}
+
+func TestLimitsWrapperReportsErrors(t *testing.T) {
+	a := &nativeAdapter{}
+
+	// An absolute path is not looked up before the start, the wrapper's
+	// exec fails and the error comes back as a run that never started
+	result, err := a.ExecuteCmd(Task{
+		Name:    "missing",
+		Command: "/proc/self/missing",
+		Nice:    5,
+	})
+	if err == nil || result.ExitCode != -1 || !strings.Contains(result.Reason, "exec /proc/self/missing") {
+		t.Errorf("got %v exit %d reason %q, want the wrapper exec error", err, result.ExitCode, result.Reason)
+	}
+}
+
+func TestMemorySignalIsOnlyBlamedWithALimit(t *testing.T) {
+	a := &nativeAdapter{}
+
+	task := Task{Name: "segv", Command: "sh", Args: "-c 'kill -SEGV $$'"}
+	result, _ := a.ExecuteCmd(task)
+	if strings.Contains(result.Reason, "max_memory") {
+		t.Errorf("no max_memory set, reason %q", result.Reason)
+	}
+
+	task.MaxMemory = "1G"
+	result, _ = a.ExecuteCmd(task)
+	if !strings.Contains(result.Reason, "inferred cause: max_memory") {
+		t.Errorf("reason %q should infer the max_memory limit", result.Reason)
+	}
+}
+
+func TestLimitsWithUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("switching user requires root")
+	}
+	if err := checkCredential(Task{Name: "as_nobody", User: "nobody"}); err != nil {
+		t.Skip("nobody user not available:", err)
+	}
+	a := &nativeAdapter{}
+
+	// A negative nice needs root, the wrapper sets it before switching user
+	result, err := a.ExecuteCmd(Task{
+		Name:    "as_nobody",
+		Command: "sh",
+		Args:    "-c 'id -un; cut -d \" \" -f 19 /proc/self/stat'",
+		User:    "nobody",
+		Nice:    -5,
+	})
+	if err != nil {
+		t.Fatal(err, result.Output)
+	}
+	if got := strings.Join(strings.Fields(result.Output), " "); got != "nobody -5" {
+		t.Errorf("got %q, want nobody -5", got)
+	}
+}
+
+func TestLimitsWrapperEnvIsRemoved(t *testing.T) {
+	a := &nativeAdapter{}
+
+	result, err := a.ExecuteCmd(Task{
+		Name:    "env",
+		Command: "env",
+		Env:     map[string]string{"REPORT": "monthly"},
+		Nice:    5,
+	})
+	if err != nil {
+		t.Fatal(err, result.Output)
+	}
+	if strings.Contains(result.Output, limitsEnv) || !strings.Contains(result.Output, "REPORT=monthly") {
+		t.Errorf("environment of the command:\n%s", result.Output)
+	}
+}
+
+func TestLimitsNeedTheWrapper(t *testing.T) {
+	limitsWrapper = false
+	defer func() { limitsWrapper = true }()
+	a := &nativeAdapter{}
+
+	result, err := a.ExecuteCmd(Task{Name: "nice", Command: "true", Nice: 5})
+	if err == nil || !strings.Contains(result.Reason, "RunLimitsWrapper") {
+		t.Errorf("got %v reason %q, want the missing wrapper error", err, result.Reason)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+
+package crontask
+
+import (
+	"os"
+	"os/exec"
+)
+
+const limitsSupported = false
+
+// startWithLimits starts cmd, the task limits are only supported on linux
+func startWithLimits(cmd *exec.Cmd, l resourceLimits) error {
+	if l.isSet() {
+		return newErr("resource limits are only supported on linux")
+	}
+	return cmd.Start()
+}
+
+// RunLimitsWrapper returns at once, the task limits are only supported on linux
+func RunLimitsWrapper() {}
+
+// limitExceeded explains a process death caused by one of the task limits
+func limitExceeded(state *os.ProcessState, l resourceLimits) string {
+	return ""
+}
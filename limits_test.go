@@ -0,0 +1,63 @@
+package crontask
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// The tests of the task limits start the test binary as the wrapper
+func TestMain(m *testing.M) {
+	RunLimitsWrapper()
+	os.Exit(m.Run())
+}
+
+func TestTaskResourceLimits(t *testing.T) {
+	l, err := Task{Nice: 10, IONice: "best-effort:7", MaxMemory: "512M", MaxCPUTime: "90s", MaxOpenFiles: 64}.resourceLimits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := resourceLimits{nice: 10, ioClass: ioClassBestEffort, ioLevel: 7, memory: 512 << 20, cpuTime: 90 * time.Second, openFiles: 64}
+	if l != want {
+		t.Errorf("resourceLimits() = %+v, want %+v", l, want)
+	}
+
+	if l, _ := (Task{}).resourceLimits(); l.isSet() {
+		t.Error("task without limits should not set any")
+	}
+
+	invalid := []Task{
+		{Nice: 20},
+		{Nice: -21},
+		{IONice: "fast"},
+		{IONice: "idle:3"},
+		{IONice: "realtime:8"},
+		{MaxMemory: "lots"},
+		{MaxMemory: "0"},
+		{MaxCPUTime: "10"},
+		{MaxCPUTime: "-1s"},
+		{MaxOpenFiles: -1},
+	}
+	for _, task := range invalid {
+		if _, err := task.resourceLimits(); err == nil {
+			t.Errorf("%+v should be rejected", task)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	sizes := map[string]uint64{
+		"1024":  1024,
+		"64K":   64 << 10,
+		"512M":  512 << 20,
+		"512MB": 512 << 20,
+		"2GiB":  2 << 30,
+		"1t":    1 << 40,
+	}
+	for s, want := range sizes {
+		got, err := parseSize(s)
+		if err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package crontask
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// discardLogger drops everything, for the loaders that have nothing to report
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newDefaultLogger is the logger used without Config.Logger: text on stdout
+// at level Info, and on Config.LogFile too when set
+func newDefaultLogger(logFile string) *slog.Logger {
+	var out io.Writer = os.Stdout
+	var fileErr error
+	if logFile != "" {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err == nil {
+			out = io.MultiWriter(os.Stdout, f)
+		}
+		fileErr = err
+	}
+
+	logger := slog.New(slog.NewTextHandler(out, nil))
+	if fileErr != nil {
+		logger.Error("unable to open the log file, logging to stdout only", "path", logFile, "error", fileErr)
+	}
+	return logger
+}
+
+// Logger returns the structured logger of the engine, the secrets of the
+// loaded tasks are masked in its messages and attributes
+func (c *CronTaskEngine) Logger() *slog.Logger {
+	return c.logger
+}
+
+// secretsHandler masks the secrets of the loaded tasks in every record
+type secretsHandler struct {
+	slog.Handler
+	engine *CronTaskEngine
+}
+
+func (h secretsHandler) Handle(ctx context.Context, r slog.Record) error {
+	secrets := h.engine.currentSecrets()
+	if len(secrets) == 0 {
+		return h.Handler.Handle(ctx, r)
+	}
+	masked := slog.NewRecord(r.Time, r.Level, maskSecrets(r.Message, secrets), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		masked.AddAttrs(maskAttr(a, secrets))
+		return true
+	})
+	return h.Handler.Handle(ctx, masked)
+}
+
+func (h secretsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	secrets := h.engine.currentSecrets()
+	masked := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		masked[i] = maskAttr(a, secrets)
+	}
+	return secretsHandler{h.Handler.WithAttrs(masked), h.engine}
+}
+
+func (h secretsHandler) WithGroup(name string) slog.Handler {
+	return secretsHandler{h.Handler.WithGroup(name), h.engine}
+}
+
+// maskAttr hides the secrets in string and error values, groups included
+func maskAttr(a slog.Attr, secrets []string) slog.Attr {
+	if len(secrets) == 0 {
+		return a
+	}
+	switch v := a.Value.Resolve(); v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, maskSecrets(v.String(), secrets))
+	case slog.KindGroup:
+		group := v.Group()
+		masked := make([]any, len(group))
+		for i, member := range group {
+			masked[i] = maskAttr(member, secrets)
+		}
+		return slog.Group(a.Key, masked...)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, maskSecrets(err.Error(), secrets))
+		}
+	}
+	return a
+}
@@ -0,0 +1,92 @@
+package crontask
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "crontasks.yml")
+	yml := "- name: hello\n  schedule: \"0 7 * * *\"\n  command: echo\n  args: \"hi\"\n"
+	if err := os.WriteFile(path, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(wd) })
+	os.Chdir(dir)
+
+	var logged bytes.Buffer
+	c := NewCronTaskEngine(Config{
+		TasksPath:      path,
+		NoAutoSchedule: true,
+		Logger:         slog.New(slog.NewJSONHandler(&logged, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+	if c.Logger() == nil {
+		t.Fatal("engine without logger")
+	}
+	if _, err := c.RunTask("hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	var finished map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logged.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("not a JSON record %q: %v", line, err)
+		}
+		if record["msg"] == "task succeeded" {
+			finished = record
+		}
+	}
+	if finished == nil {
+		t.Fatalf("no task succeeded record:\n%s", logged.String())
+	}
+	if finished["task"] != "hello" || finished["run_id"] != 1.0 || finished["exit_code"] != 0.0 {
+		t.Errorf("record %v", finished)
+	}
+	if !strings.Contains(logged.String(), `"msg":"task output"`) {
+		t.Errorf("no debug output record:\n%s", logged.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "log.txt")); !os.IsNotExist(err) {
+		t.Errorf("log.txt created without Config.LogFile: %v", err)
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "crontask.log")
+	c := NewCronTaskEngine(Config{TasksPath: filepath.Join(t.TempDir(), "missing.yml"), NoAutoSchedule: true, LogFile: file})
+	c.Logger().Info("written to the file")
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `msg="written to the file"`) {
+		t.Errorf("log file:\n%s", data)
+	}
+}
+
+func TestDeprecatedLog(t *testing.T) {
+	var logged bytes.Buffer
+	c := NewCronTaskEngine(Config{
+		TasksPath:      filepath.Join(t.TempDir(), "crontasks.yml"),
+		NoAutoSchedule: true,
+		Logger:         slog.New(slog.NewJSONHandler(&logged, nil)),
+	})
+	logged.Reset()
+
+	c.Log("Loading tasks from", "crontasks.yml", 3)
+	var record map[string]any
+	if err := json.Unmarshal(logged.Bytes(), &record); err != nil {
+		t.Fatalf("not a JSON record %q: %v", logged.String(), err)
+	}
+	if record["level"] != "INFO" || record["msg"] != "Loading tasks from crontasks.yml 3" {
+		t.Errorf("record %v", record)
+	}
+}
@@ -0,0 +1,179 @@
+package crontask
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the upper bounds in seconds of the duration histogram,
+// from quick scripts to hour long backups
+var durationBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600}
+
+// metrics counts the finished runs by task
+type metrics struct {
+	mu    sync.Mutex
+	tasks map[string]*taskMetrics
+}
+
+type taskMetrics struct {
+	runs        map[RunStatus]uint64
+	buckets     []uint64 // runs per durationBuckets, not cumulative
+	durationSum float64
+	durationN   uint64
+	lastSuccess time.Time
+}
+
+// observe counts a finished run
+func (m *metrics) observe(result RunResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tasks == nil {
+		m.tasks = make(map[string]*taskMetrics)
+	}
+	t, ok := m.tasks[result.Task]
+	if !ok {
+		t = &taskMetrics{runs: make(map[RunStatus]uint64), buckets: make([]uint64, len(durationBuckets))}
+		m.tasks[result.Task] = t
+	}
+
+	t.runs[result.Status]++
+	if result.Status == StatusSkipped {
+		return // nothing ran, its duration would skew the histogram
+	}
+	seconds := result.Duration.Seconds()
+	if i, _ := slices.BinarySearch(durationBuckets, seconds); i < len(durationBuckets) {
+		t.buckets[i]++
+	}
+	t.durationSum += seconds
+	t.durationN++
+	if result.Status == StatusSuccess {
+		t.lastSuccess = result.Start.Add(result.Duration)
+	}
+}
+
+// MetricsHandler returns an http.Handler writing the metrics of the engine in
+// the Prometheus text exposition format eg:
+//
+//	http.Handle("/metrics", engine.MetricsHandler())
+//
+// Metrics: crontask_runs_total{task,outcome}, crontask_skipped_total{task},
+// crontask_duration_seconds{task} (histogram), crontask_running{task},
+// crontask_last_success_timestamp{task} and crontask_next_run_timestamp{task},
+// timestamps in Unix seconds.
+func (c *CronTaskEngine) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		out := bufio.NewWriter(w)
+		c.writeMetrics(out, time.Now())
+		out.Flush()
+	})
+}
+
+// writeMetrics writes the metrics in the Prometheus text format, tasks sorted by name
+func (c *CronTaskEngine) writeMetrics(w *bufio.Writer, now time.Time) {
+	tasks := c.GetTasks()
+	running := make(map[string]int)
+	for _, run := range c.Running() {
+		running[run.Task]++
+	}
+
+	c.metrics.mu.Lock()
+	defer c.metrics.mu.Unlock()
+
+	// Every loaded, run or running task appears in every metric
+	names := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		names = append(names, t.Name)
+	}
+	for name := range c.metrics.tasks {
+		names = append(names, name)
+	}
+	for name := range running {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	empty := &taskMetrics{runs: map[RunStatus]uint64{}, buckets: make([]uint64, len(durationBuckets))}
+	stats := func(name string) *taskMetrics {
+		if t, ok := c.metrics.tasks[name]; ok {
+			return t
+		}
+		return empty
+	}
+
+	header(w, "crontask_runs_total", "counter", "Finished runs by task and outcome.")
+	for _, name := range names {
+		for _, status := range []RunStatus{StatusSuccess, StatusFailed, StatusSkipped} {
+			sample(w, "crontask_runs_total", labels("task", name, "outcome", string(status)), float64(stats(name).runs[status]))
+		}
+	}
+
+	header(w, "crontask_skipped_total", "counter", "Skipped runs by task.")
+	for _, name := range names {
+		sample(w, "crontask_skipped_total", labels("task", name), float64(stats(name).runs[StatusSkipped]))
+	}
+
+	header(w, "crontask_duration_seconds", "histogram", "Duration of the runs that were not skipped.")
+	for _, name := range names {
+		t := stats(name)
+		var cumulative uint64
+		for i, le := range durationBuckets {
+			cumulative += t.buckets[i]
+			sample(w, "crontask_duration_seconds_bucket", labels("task", name, "le", formatFloat(le)), float64(cumulative))
+		}
+		sample(w, "crontask_duration_seconds_bucket", labels("task", name, "le", "+Inf"), float64(t.durationN))
+		sample(w, "crontask_duration_seconds_sum", labels("task", name), t.durationSum)
+		sample(w, "crontask_duration_seconds_count", labels("task", name), float64(t.durationN))
+	}
+
+	header(w, "crontask_running", "gauge", "Runs in progress by task.")
+	for _, name := range names {
+		sample(w, "crontask_running", labels("task", name), float64(running[name]))
+	}
+
+	header(w, "crontask_last_success_timestamp", "gauge", "End of the last successful run in Unix seconds, tasks that never succeeded are absent.")
+	for _, name := range names {
+		if last := stats(name).lastSuccess; !last.IsZero() {
+			sample(w, "crontask_last_success_timestamp", labels("task", name), float64(last.UnixMilli())/1000)
+		}
+	}
+
+	header(w, "crontask_next_run_timestamp", "gauge", "Next scheduled run in Unix seconds, tasks without a schedule are absent.")
+	for _, t := range tasks {
+		if runs, err := NextRuns(t.Schedule, now, 1); err == nil && len(runs) > 0 {
+			sample(w, "crontask_next_run_timestamp", labels("task", t.Name), float64(runs[0].Unix()))
+		}
+	}
+}
+
+func header(w *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func sample(w *bufio.Writer, name, labels string, value float64) {
+	fmt.Fprintf(w, "%s{%s} %s\n", name, labels, formatFloat(value))
+}
+
+// labels formats name/value pairs as `a="1",b="2"`
+func labels(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i] + `="` + labelEscaper.Replace(pairs[i+1]) + `"`)
+	}
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,56 @@
+package crontask
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	a := &fakeAdapter{exitCodes: map[string]int{"bad": 1, "idle": 3}}
+	c := newFakeEngine(a,
+		Task{Name: "ok", Schedule: "0 1 * * *"},
+		Task{Name: "bad"},
+		Task{Name: "idle", SkipCodes: []int{3}},
+		Task{Name: `quo"te`},
+	)
+	for _, name := range []string{"ok", "ok", "bad", "idle"} {
+		c.ExecuteTask(name)
+	}
+	c.metrics.observe(RunResult{Task: "slow", Status: StatusSuccess, Start: time.Unix(1700000000, 0), Duration: 45 * time.Second})
+
+	rec := httptest.NewRecorder()
+	c.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("content type %q", ct)
+	}
+	for _, line := range []string{
+		"# TYPE crontask_runs_total counter",
+		`crontask_runs_total{task="ok",outcome="success"} 2`,
+		`crontask_runs_total{task="bad",outcome="failed"} 1`,
+		`crontask_runs_total{task="idle",outcome="skipped"} 1`,
+		`crontask_skipped_total{task="idle"} 1`,
+		`crontask_skipped_total{task="quo\"te"} 0`,
+		"# TYPE crontask_duration_seconds histogram",
+		`crontask_duration_seconds_bucket{task="slow",le="30"} 0`,
+		`crontask_duration_seconds_bucket{task="slow",le="60"} 1`,
+		`crontask_duration_seconds_bucket{task="slow",le="+Inf"} 1`,
+		`crontask_duration_seconds_sum{task="slow"} 45`,
+		`crontask_duration_seconds_count{task="idle"} 0`,
+		`crontask_running{task="ok"} 0`,
+		`crontask_last_success_timestamp{task="slow"} 1700000045`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, `crontask_last_success_timestamp{task="bad"}`) {
+		t.Error("a task that never succeeded has a last success")
+	}
+	if !strings.Contains(body, `crontask_next_run_timestamp{task="ok"} `) || strings.Contains(body, `crontask_next_run_timestamp{task="bad"}`) {
+		t.Errorf("next run timestamps in\n%s", body)
+	}
+}
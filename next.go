@@ -0,0 +1,58 @@
+package crontask
+
+import "time"
+
+// nextRunsHorizon bounds the search for schedules that match rarely or never
+const nextRunsHorizon = 5 * 366 * 24 * time.Hour
+
+// NextRuns returns the next n times after from at which the scheduler would
+// run schedule, in the location of from. Fewer are returned when the schedule
+// doesn't match that often within five years.
+func NextRuns(schedule string, from time.Time, n int) ([]time.Time, error) {
+	j, err := parseSchedule(schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []time.Time
+	limit := from.Add(nextRunsHorizon)
+	t := from.Truncate(time.Minute).Add(time.Minute)
+	for len(runs) < n {
+		run, ok := j.nextMatch(t, limit)
+		if !ok {
+			break
+		}
+		runs = append(runs, run)
+		t = run.Add(time.Minute)
+	}
+	return runs, nil
+}
+
+// nextMatch returns the first minute from t, included, at which the job runs,
+// false when there is none before limit
+func (j *job) nextMatch(t, limit time.Time) (time.Time, bool) {
+	for t.Before(limit) {
+		// Skip whole months, days and hours that can't match
+		y, m, d := t.Date()
+		tk := getTick(t)
+		_, day := j.day[tk.day]
+		_, dayOfWeek := j.dayOfWeek[tk.dayOfWeek]
+		if _, ok := j.month[tk.month]; !ok {
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !day && !dayOfWeek {
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if _, ok := j.hour[tk.hour]; !ok {
+			t = time.Date(y, m, d, tk.hour+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if j.tick(tk) {
+			return t, true
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}, false
+}
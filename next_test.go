@@ -0,0 +1,57 @@
+package crontask
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRuns(t *testing.T) {
+	from := time.Date(2026, 1, 1, 10, 7, 30, 0, time.UTC) // a thursday
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		schedule string
+		n        int
+		want     []time.Time
+	}{
+		{"*/15 * * * *", 3, []time.Time{at(1, 1, 10, 15), at(1, 1, 10, 30), at(1, 1, 10, 45)}},
+		{"0 7 * * 1,4", 3, []time.Time{at(1, 5, 7, 0), at(1, 8, 7, 0), at(1, 12, 7, 0)}},
+		{"30 2 1 */6 *", 2, []time.Time{at(7, 1, 2, 30), time.Date(2027, 1, 1, 2, 30, 0, 0, time.UTC)}},
+		// day of month and day of week are combined like cron: the 15th or any sunday
+		{"0 0 15 * 0", 3, []time.Time{at(1, 4, 0, 0), at(1, 11, 0, 0), at(1, 15, 0, 0)}},
+		// a run at from itself is not next
+		{"7 10 * * *", 1, []time.Time{at(1, 2, 10, 7)}},
+	}
+	for _, tt := range tests {
+		got, err := NextRuns(tt.schedule, from, tt.n)
+		if err != nil {
+			t.Errorf("%s: %v", tt.schedule, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.schedule, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !got[i].Equal(tt.want[i]) {
+				t.Errorf("%s run %d: got %v, want %v", tt.schedule, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestNextRunsNeverMatching(t *testing.T) {
+	runs, err := NextRuns("0 0 31 2 *", time.Now(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("february 31 matched %v", runs)
+	}
+
+	if _, err := NextRuns("* * *", time.Now(), 1); err == nil {
+		t.Error("expected an error for a three field schedule")
+	}
+}
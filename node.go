@@ -0,0 +1,277 @@
+package crontask
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// nodeKind is the shape of a parsed document node
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	mappingNode
+	sequenceNode
+)
+
+// node is a format independent document tree with positions, built by the
+// YAML, JSON and TOML parsers so the decoder and Validate can report file,
+// line and column the same way for all of them
+type node struct {
+	kind   nodeKind
+	value  string     // scalarNode
+	isNull bool       // scalarNode written as null, ~ or nothing
+	pairs  []nodePair // mappingNode, in document order
+	items  []*node    // sequenceNode
+	line   int
+	column int
+}
+
+type nodePair struct {
+	key   *node
+	value *node
+}
+
+// position is a location inside a tasks file
+type position struct {
+	file   string
+	line   int
+	column int
+}
+
+// nodeDecoder fills Go values from a node tree using their yaml tags,
+// collecting every problem instead of stopping at the first one
+type nodeDecoder struct {
+	file      string
+	positions map[string]position // path eg: "tasks[2].schedule" => value position
+	problems  ValidationErrors
+	secrets   map[string][]string // owner eg: "tasks[2]" or "templates.db_job" => secret values interpolated in it
+}
+
+func (d *nodeDecoder) problem(n *node, message string) {
+	d.problems = append(d.problems, ValidationError{File: d.file, Line: n.line, Column: n.column, Message: message})
+}
+
+// decodeTaskFile decodes a document that is either a list of tasks or a mapping
+// with include:, tasks: and workflows: keys
+func decodeTaskFile(root *node, file string) (taskFile, ValidationErrors) {
+	d := &nodeDecoder{file: file, positions: make(map[string]position), secrets: make(map[string][]string)}
+	var tf taskFile
+
+	switch {
+	case root == nil || root.isNull:
+		d.problems = append(d.problems, ValidationError{File: file, Line: 1, Column: 1, Message: "empty tasks file"})
+	case root.kind == sequenceNode:
+		d.decode(root, reflect.ValueOf(&tf.Tasks).Elem(), "tasks")
+	case root.kind == mappingNode:
+		d.decode(root, reflect.ValueOf(&tf).Elem(), "")
+	default:
+		d.problem(root, "tasks file must be a list of tasks or a mapping with a tasks: key")
+	}
+
+	for i := range tf.Tasks {
+		tf.Tasks[i].secrets = d.secrets["tasks["+strconv.Itoa(i)+"]"]
+	}
+	tf.Defaults.secrets = d.secrets["defaults"]
+	for name, t := range tf.Templates {
+		t.secrets = d.secrets["templates."+name]
+		tf.Templates[name] = t
+	}
+	for _, secrets := range d.secrets {
+		tf.secrets = append(tf.secrets, secrets...)
+	}
+	tf.positions = d.positions
+	return tf, d.problems
+}
+
+func (d *nodeDecoder) decode(n *node, v reflect.Value, path string) {
+	d.positions[path] = position{file: d.file, line: n.line, column: n.column}
+	if n.isNull {
+		return // keep the zero value
+	}
+
+	value := n.value
+	if n.kind == scalarNode {
+		var secrets []string
+		var err error
+		if value, secrets, err = interpolate(n.value); err != nil {
+			d.problem(n, fieldName(path)+": "+err.Error())
+			return
+		}
+		d.addSecrets(path, secrets)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		d.decodeStruct(n, v, path)
+
+	case reflect.Slice:
+		if n.kind != sequenceNode {
+			d.problem(n, fieldName(path)+": expected a list")
+			return
+		}
+		slice := reflect.MakeSlice(v.Type(), len(n.items), len(n.items))
+		for i, item := range n.items {
+			d.decode(item, slice.Index(i), path+"["+strconv.Itoa(i)+"]")
+		}
+		v.Set(slice)
+
+	case reflect.Map:
+		if n.kind != mappingNode {
+			d.problem(n, fieldName(path)+": expected a mapping")
+			return
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(n.pairs))
+		for _, p := range n.pairs {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			d.decode(p.value, elem, path+"."+p.key.value)
+			m.SetMapIndex(reflect.ValueOf(p.key.value), elem)
+		}
+		v.Set(m)
+
+	case reflect.String:
+		if n.kind != scalarNode {
+			d.problem(n, fieldName(path)+": expected a text value")
+			return
+		}
+		v.SetString(value)
+
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if n.kind != scalarNode || err != nil {
+			d.problem(n, fieldName(path)+": expected an integer")
+			return
+		}
+		v.SetInt(i)
+
+	case reflect.Bool:
+		b, ok := parseBool(value)
+		if n.kind != scalarNode || !ok {
+			d.problem(n, fieldName(path)+": expected true or false")
+			return
+		}
+		v.SetBool(b)
+	}
+}
+
+// addSecrets keeps the secrets interpolated at path for the task, template
+// or defaults they belong to
+func (d *nodeDecoder) addSecrets(path string, secrets []string) {
+	if len(secrets) == 0 {
+		return
+	}
+	owner := "" // eg: an include
+	switch {
+	case strings.HasPrefix(path, "tasks["):
+		owner = path[:strings.IndexByte(path, ']')+1]
+	case strings.HasPrefix(path, "defaults"):
+		owner = "defaults"
+	case strings.HasPrefix(path, "templates."):
+		name, _, _ := strings.Cut(strings.TrimPrefix(path, "templates."), ".")
+		owner = "templates." + name
+	}
+	d.secrets[owner] = append(d.secrets[owner], secrets...)
+}
+
+func (d *nodeDecoder) decodeStruct(n *node, v reflect.Value, path string) {
+	if n.kind != mappingNode {
+		message := "expected a mapping"
+		if path != "" {
+			message = fieldName(path) + ": " + message
+		}
+		d.problem(n, message)
+		return
+	}
+
+	fields := yamlFields(v.Type())
+	seen := make(map[string]bool, len(n.pairs))
+	for _, p := range n.pairs {
+		key := p.key.value
+		index, ok := fields[key]
+		if !ok {
+			d.problem(p.key, unknownKeyMessage(key, fields))
+			continue
+		}
+		if seen[key] {
+			d.problem(p.key, "duplicate key "+strconv.Quote(key))
+			continue
+		}
+		seen[key] = true
+
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		d.decode(p.value, v.Field(index), fieldPath)
+	}
+}
+
+// yamlFields maps the yaml tag of every exported field to its index
+func yamlFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+// unknownKeyMessage suggests the closest known key for a typo like "schedul"
+func unknownKeyMessage(key string, fields map[string]int) string {
+	message := "unknown key " + strconv.Quote(key)
+	best, bestDistance := "", 3 // suggest only close matches
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	if best != "" {
+		message += ", did you mean " + strconv.Quote(best) + "?"
+	}
+	return message
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// fieldName returns the last key of a path eg: "tasks[0].args" => "args"
+func fieldName(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// parseBool accepts the YAML 1.1 spellings of booleans
+func parseBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "on":
+		return true, true
+	case "false", "no", "off":
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,79 @@
+package crontask
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// RunStatus is the outcome of a single task run.
+type RunStatus string
+
+const (
+	StatusSuccess RunStatus = "success" // exit code in success_codes and output checks passed
+	StatusFailed  RunStatus = "failed"  // any other exit code, stderr output or missing success_pattern
+	StatusSkipped RunStatus = "skipped" // exit code in skip_codes, eg: "nothing to do"
+)
+
+// RunResult describes a finished task run.
+type RunResult struct {
+	Task     string        // task name
+	RunID    int64         // number of the run in the engine, 0 when it didn't start
+	Workflow string        // workflow name when the run is a workflow step
+	Status   RunStatus     // success, failed or skipped
+	ExitCode int           // process exit code, -1 when the command could not be started
+	Output   string        // combined stdout and stderr
+	Reason   string        // why the run was not a success eg: "exit code 2"
+	Start    time.Time     // when the run started
+	Duration time.Duration // how long the run took
+	TimedOut bool          // the command was killed by its timeout
+}
+
+// historySize is the number of runs kept by the engine.
+const historySize = 100
+
+// outcome decides the status of a finished run from its exit code and output.
+// skip_codes are checked first, then success_codes (default: 0), and only a
+// successful exit is further checked against fail_on_stderr and success_pattern.
+func (t Task) outcome(exitCode int, stdout, stderr string) (RunStatus, string) {
+	if containsCode(t.SkipCodes, exitCode) {
+		return StatusSkipped, "exit code " + strconv.Itoa(exitCode)
+	}
+
+	successCodes := t.SuccessCodes
+	if len(successCodes) == 0 {
+		successCodes = []int{0}
+	}
+	if !containsCode(successCodes, exitCode) {
+		return StatusFailed, "exit code " + strconv.Itoa(exitCode)
+	}
+
+	if t.FailOnStderr && len(stderr) > 0 {
+		return StatusFailed, "output on stderr"
+	}
+
+	if t.SuccessPattern != "" {
+		re := t.successRe
+		if re == nil {
+			// A task built in code and never validated
+			var err error
+			if re, err = regexp.Compile(t.SuccessPattern); err != nil {
+				return StatusFailed, "invalid success_pattern: " + err.Error()
+			}
+		}
+		if !re.MatchString(stdout) && !re.MatchString(stderr) {
+			return StatusFailed, "success_pattern not found in output"
+		}
+	}
+
+	return StatusSuccess, ""
+}
+
+func containsCode(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
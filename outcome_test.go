@@ -0,0 +1,61 @@
+package crontask
+
+import "testing"
+
+func TestTaskOutcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		task     Task
+		exitCode int
+		stdout   string
+		stderr   string
+		want     RunStatus
+	}{
+		{"zero exit is success by default", Task{}, 0, "", "", StatusSuccess},
+		{"non-zero exit is failure by default", Task{}, 1, "", "", StatusFailed},
+		{"robocopy style success code", Task{SuccessCodes: []int{0, 1}}, 1, "", "", StatusSuccess},
+		{"zero not listed in success codes", Task{SuccessCodes: []int{1}}, 0, "", "", StatusFailed},
+		{"skip code", Task{SkipCodes: []int{3}}, 3, "", "", StatusSkipped},
+		{"skip code wins over success code", Task{SuccessCodes: []int{3}, SkipCodes: []int{3}}, 3, "", "", StatusSkipped},
+		{"stderr ignored by default", Task{}, 0, "", "warning", StatusSuccess},
+		{"fail on stderr", Task{FailOnStderr: true}, 0, "ok", "warning", StatusFailed},
+		{"success pattern found", Task{SuccessPattern: `Backup (completed|done)`}, 0, "Backup done in 3s", "", StatusSuccess},
+		{"success pattern missing", Task{SuccessPattern: `Backup completed`}, 0, "Backup aborted", "", StatusFailed},
+		{"success pattern not checked on failure", Task{SuccessPattern: `.*`}, 2, "", "", StatusFailed},
+		{"invalid success pattern", Task{SuccessPattern: `(`}, 0, "", "", StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := tt.task.outcome(tt.exitCode, tt.stdout, tt.stderr)
+			if got != tt.want {
+				t.Errorf("outcome() = %s (%s), want %s", got, reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuccessPatternCompiledOnLoad(t *testing.T) {
+	a := &fakeAdapter{file: taskFile{Tasks: []Task{{Name: "backup", Command: "backup", SuccessPattern: `Backup (completed|done)`}}}}
+	c := newFakeEngine(a)
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	task := c.GetTasks()[0]
+	if task.successRe == nil || task.successRe.String() != task.SuccessPattern {
+		t.Fatalf("success_pattern not compiled on load: %v", task.successRe)
+	}
+	if status, _ := task.outcome(0, "Backup done", ""); status != StatusSuccess {
+		t.Errorf("status %s", status)
+	}
+
+	// Reloading the same file compiles the pattern again but changes nothing
+	sub := c.Subscribe()
+	defer sub.Close()
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded := <-sub.C; len(reloaded.Changed) != 0 {
+		t.Errorf("unchanged task reported as changed: %v", reloaded.Changed)
+	}
+}
@@ -0,0 +1,95 @@
+package crontask
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Pause stops the scheduled runs of a task until Resume, they are recorded
+// as skipped. Runs on demand and as a follow-up still happen. The pause
+// survives reloads.
+func (c *CronTaskEngine) Pause(task string) error {
+	if _, ok := c.findTask(task); !ok {
+		return newErr("task not found: " + task)
+	}
+	c.setPaused("task:"+task, true)
+	c.logger.Info("task paused", "task", task)
+	return nil
+}
+
+// Resume restarts the scheduled runs of a paused task
+func (c *CronTaskEngine) Resume(task string) error {
+	if !c.isPaused("task:" + task) {
+		return newErr("task not paused: " + task)
+	}
+	c.setPaused("task:"+task, false)
+	c.logger.Info("task resumed", "task", task)
+	return nil
+}
+
+// PauseWorkflow stops the scheduled runs of a workflow until ResumeWorkflow
+func (c *CronTaskEngine) PauseWorkflow(workflow string) error {
+	if _, ok := c.findWorkflow(workflow); !ok {
+		return newErr("workflow not found: " + workflow)
+	}
+	c.setPaused("workflow:"+workflow, true)
+	c.logger.Info("workflow paused", "workflow", workflow)
+	return nil
+}
+
+// ResumeWorkflow restarts the scheduled runs of a paused workflow
+func (c *CronTaskEngine) ResumeWorkflow(workflow string) error {
+	if !c.isPaused("workflow:" + workflow) {
+		return newErr("workflow not paused: " + workflow)
+	}
+	c.setPaused("workflow:"+workflow, false)
+	c.logger.Info("workflow resumed", "workflow", workflow)
+	return nil
+}
+
+// Paused returns the names of the paused tasks and workflows, sorted
+func (c *CronTaskEngine) Paused() (tasks, workflows []string) {
+	c.pausedMu.RLock()
+	defer c.pausedMu.RUnlock()
+	for id := range c.paused {
+		if name, ok := strings.CutPrefix(id, "task:"); ok {
+			tasks = append(tasks, name)
+		} else if name, ok := strings.CutPrefix(id, "workflow:"); ok {
+			workflows = append(workflows, name)
+		}
+	}
+	slices.Sort(tasks)
+	slices.Sort(workflows)
+	return tasks, workflows
+}
+
+// isPaused reports if the job id, "task:<name>" or "workflow:<name>", is paused
+func (c *CronTaskEngine) isPaused(id string) bool {
+	c.pausedMu.RLock()
+	defer c.pausedMu.RUnlock()
+	return c.paused[id]
+}
+
+func (c *CronTaskEngine) setPaused(id string, paused bool) {
+	c.pausedMu.Lock()
+	defer c.pausedMu.Unlock()
+	if !paused {
+		delete(c.paused, id)
+		return
+	}
+	if c.paused == nil {
+		c.paused = make(map[string]bool)
+	}
+	c.paused[id] = true
+}
+
+// runScheduled runs a task fired by the scheduler unless it is paused
+func (c *CronTaskEngine) runScheduled(task Task) {
+	if c.isPaused("task:" + task.Name) {
+		c.logger.Info("task paused, skipping its scheduled run", "task", task.Name)
+		c.finished(task, RunResult{Task: task.Name, Status: StatusSkipped, ExitCode: -1, Start: time.Now(), Reason: "paused"}, nil)
+		return
+	}
+	c.runTask(task)
+}
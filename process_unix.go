@@ -0,0 +1,28 @@
+//go:build !wasm && !windows
+
+package crontask
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in its own process group: a Ctrl+C on the
+// daemon's terminal doesn't reach the tasks and signalProcess reaches the
+// children the task starts too
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// signalProcess sends SIGTERM, or SIGKILL when kill is set, to the process
+// group started by setProcessGroup
+func signalProcess(pid int, kill bool) {
+	sig := syscall.SIGTERM
+	if kill {
+		sig = syscall.SIGKILL
+	}
+	syscall.Kill(-pid, sig)
+}
@@ -0,0 +1,56 @@
+//go:build !wasm && !windows
+
+package crontask
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdownStopsChildProcesses(t *testing.T) {
+	a := newCronAdapter(discardLogger).(*nativeAdapter)
+	c := &CronTaskEngine{adapter: a, logger: discardLogger, quit: make(chan struct{})}
+	c.tasks = []Task{
+		// The shell waits for sleep, SIGTERM must reach the whole group
+		{Name: "polite", Command: "sh", Args: "-c 'sleep 30; echo done'"},
+		{Name: "stubborn", Command: "sh", Args: "-c 'trap \"\" TERM; sleep 30 & wait'"},
+	}
+
+	results := make(chan RunResult, 2)
+	for _, name := range []string{"polite", "stubborn"} {
+		go func() {
+			result, _ := c.RunTask(name)
+			results <- result
+		}()
+	}
+	waitFor(t, func() bool {
+		a.procsMu.Lock()
+		defer a.procsMu.Unlock()
+		return len(a.procs) == 2
+	})
+	time.Sleep(100 * time.Millisecond) // the stubborn shell sets its trap
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	err := c.Shutdown(ctx)
+	if err == nil || !strings.Contains(err.Error(), "killed the running tasks: stubborn") {
+		t.Errorf("Shutdown = %v, want stubborn killed at the deadline", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Shutdown waited for the children")
+	}
+	for i := 0; i < 2; i++ {
+		if result := <-results; result.Status != StatusFailed {
+			t.Errorf("%s = %+v, want failed", result.Task, result)
+		}
+	}
+
+	select {
+	case <-a.ctab.done:
+	default:
+		t.Error("the scheduler ticker was not stopped")
+	}
+}
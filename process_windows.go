@@ -0,0 +1,29 @@
+//go:build !wasm && windows
+
+package crontask
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in its own process group so a Ctrl+C on the
+// daemon's console doesn't reach the tasks
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// signalProcess terminates the process when kill is set, windows has no
+// SIGTERM to ask it to stop first
+func signalProcess(pid int, kill bool) {
+	if !kill {
+		return
+	}
+	if p, err := os.FindProcess(pid); err == nil {
+		p.Kill()
+	}
+}
@@ -0,0 +1,213 @@
+package crontask
+
+import (
+	"reflect"
+	"slices"
+	"time"
+)
+
+// defaultWatchInterval is the polling interval used when Config.WatchInterval is not set
+const defaultWatchInterval = 5 * time.Second
+
+// reloadDebounce groups the burst of events an editor produces when saving a file
+const reloadDebounce = 200 * time.Millisecond
+
+// Reload reads the tasks file again and applies only the differences: new tasks
+// are scheduled, removed ones unscheduled and changed ones rescheduled. Runs in
+// progress are not interrupted. An invalid file is rejected and the previous
+// configuration stays active.
+func (c *CronTaskEngine) Reload() error {
+	// Two overlapping reloads would diff against the same old set and
+	// schedule the same job twice
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	// The adapter validates the whole task set while loading it
+	file, err := c.adapter.GetTasksFromPath(c.tasksPath, c.format)
+	if err != nil {
+		return newErr("reload rejected, keeping previous tasks:", err)
+	}
+
+	c.mu.Lock()
+	oldTasks, oldWorkflows := c.tasks, c.workflows
+	c.tasks, c.workflows = file.Tasks, file.Workflows
+	scheduled := c.scheduled || c.autoSchedule
+	c.scheduled = scheduled
+	sourcesChanged := !slices.Equal(c.sources, file.sources)
+	c.sources = file.sources
+	c.mu.Unlock()
+	c.setSecrets(file.secrets)
+	if sourcesChanged {
+		select {
+		case c.sourcesChanged <- struct{}{}:
+		default: // the watcher has not restarted since the last change
+		}
+	}
+
+	var added, removed, changed []string
+
+	oldByName := make(map[string]Task, len(oldTasks))
+	for _, t := range oldTasks {
+		oldByName[t.Name] = t
+	}
+	for _, t := range file.Tasks {
+		old, ok := oldByName[t.Name]
+		delete(oldByName, t.Name)
+		switch {
+		case !ok:
+			added = append(added, t.Name)
+		case !sameTask(old, t):
+			changed = append(changed, t.Name)
+			if scheduled {
+				c.adapter.UnscheduleJob("task:" + t.Name)
+			}
+		default:
+			continue
+		}
+		if scheduled {
+			if err := c.scheduleTask(t); err != nil {
+				c.logger.Error("scheduling task failed", "task", t.Name, "error", err)
+			}
+		}
+	}
+	for name := range oldByName {
+		removed = append(removed, name)
+		if scheduled {
+			c.adapter.UnscheduleJob("task:" + name)
+		}
+	}
+
+	oldWorkflowByName := make(map[string]Workflow, len(oldWorkflows))
+	for _, wf := range oldWorkflows {
+		oldWorkflowByName[wf.Name] = wf
+	}
+	for _, wf := range file.Workflows {
+		old, ok := oldWorkflowByName[wf.Name]
+		delete(oldWorkflowByName, wf.Name)
+		switch {
+		case !ok:
+			added = append(added, "workflow "+wf.Name)
+		case !reflect.DeepEqual(old, wf):
+			changed = append(changed, "workflow "+wf.Name)
+			if scheduled {
+				c.adapter.UnscheduleJob("workflow:" + wf.Name)
+			}
+		default:
+			continue
+		}
+		if scheduled {
+			if err := c.scheduleWorkflow(wf); err != nil {
+				c.logger.Error("scheduling workflow failed", "workflow", wf.Name, "error", err)
+			}
+		}
+	}
+	for name := range oldWorkflowByName {
+		removed = append(removed, "workflow "+name)
+		if scheduled {
+			c.adapter.UnscheduleJob("workflow:" + name)
+		}
+	}
+
+	c.logger.Info("tasks reloaded", "path", c.tasksPath, "added", added, "removed", removed, "changed", changed)
+	c.emit(Event{Type: EventConfigReloaded, Added: added, Removed: removed, Changed: changed})
+	return nil
+}
+
+// sameTask reports whether a reloaded task is unchanged, the compiled
+// success_pattern is left out as every load compiles a new one
+func sameTask(a, b Task) bool {
+	a.successRe, b.successRe = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// watchTasksFile reloads the tasks file every time it changes on disk. The
+// watch restarts after a reload that reads other files, eg: a new include
+func (c *CronTaskEngine) watchTasksFile(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	changes, stop, err := c.watchSources(interval)
+	if err != nil {
+		c.logger.Error("unable to watch the tasks file", "path", c.tasksPath, "error", err)
+		return
+	}
+	c.logger.Info("watching the tasks file", "path", c.tasksPath)
+
+	events := make(chan struct{}, 1)
+	go c.reloadOn(events)
+	go func() {
+		for {
+			select {
+			case <-c.quit:
+				close(stop)
+				return
+			case <-changes:
+				select {
+				case events <- struct{}{}:
+				default: // a change is already pending
+				}
+			case <-c.sourcesChanged:
+				close(stop)
+				if changes, stop, err = c.watchSources(interval); err != nil {
+					c.logger.Error("unable to watch the tasks files", "path", c.tasksPath, "error", err)
+					return
+				}
+			}
+		}
+	}()
+}
+
+// watchSources watches the files read on the last load until stop is closed
+func (c *CronTaskEngine) watchSources(interval time.Duration) (changes <-chan struct{}, stop chan struct{}, err error) {
+	c.mu.RLock()
+	sources := c.sources
+	c.mu.RUnlock()
+
+	stop = make(chan struct{})
+	if changes, err = watchTasksFiles(c.tasksPath, sources, interval, stop); err != nil {
+		return nil, nil, err
+	}
+	return changes, stop, nil
+}
+
+// reloadOnSignal reloads the tasks file when the process receives SIGHUP
+func (c *CronTaskEngine) reloadOnSignal() {
+	signals, err := notifyReload(c.quit)
+	if err != nil {
+		c.logger.Error("unable to reload on signal", "error", err)
+		return
+	}
+	go c.reloadOn(signals)
+}
+
+// reloadOn calls Reload for every event, waiting reloadDebounce for the events to settle
+func (c *CronTaskEngine) reloadOn(events <-chan struct{}) {
+	for {
+		select {
+		case <-c.quit:
+			return
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		}
+
+		settle := time.NewTimer(reloadDebounce)
+	drain:
+		for {
+			select {
+			case <-events:
+				settle.Reset(reloadDebounce)
+			case <-settle.C:
+				break drain
+			case <-c.quit:
+				settle.Stop()
+				return
+			}
+		}
+
+		if err := c.Reload(); err != nil {
+			c.logger.Error("reload failed", "path", c.tasksPath, "error", err)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package crontask
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReloadAppliesDifferences(t *testing.T) {
+	a := &fakeAdapter{}
+	c := newFakeEngine(a,
+		Task{Name: "kept", Schedule: "0 1 * * *", Command: "echo"},
+		Task{Name: "changed", Schedule: "0 2 * * *", Command: "echo"},
+		Task{Name: "removed", Schedule: "0 3 * * *", Command: "echo"},
+	)
+	if err := c.ScheduleAllTasks(); err != nil {
+		t.Fatal(err)
+	}
+
+	a.file = taskFile{Tasks: []Task{
+		{Name: "kept", Schedule: "0 1 * * *", Command: "echo"},
+		{Name: "changed", Schedule: "30 2 * * *", Command: "echo"},
+		{Name: "added", Schedule: "0 4 * * *", Command: "echo"},
+	}}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"task:kept": "0 1 * * *", "task:changed": "30 2 * * *", "task:added": "0 4 * * *"}
+	if !reflect.DeepEqual(a.jobs, want) {
+		t.Errorf("scheduled jobs %v, want %v", a.jobs, want)
+	}
+	if got := c.GetTasks(); !reflect.DeepEqual(got, a.file.Tasks) {
+		t.Errorf("engine tasks %v, want %v", got, a.file.Tasks)
+	}
+}
+
+func TestReloadRejectsInvalidFile(t *testing.T) {
+	previous := []Task{{Name: "backup", Schedule: "0 1 * * *", Command: "echo"}}
+
+	invalid := map[string]taskFile{
+		"bad schedule":      {Tasks: []Task{{Name: "backup", Schedule: "0 25 * * *"}}},
+		"duplicate name":    {Tasks: []Task{{Name: "backup"}, {Name: "backup"}}},
+		"unknown follow-up": {Tasks: []Task{{Name: "backup", OnSuccess: []string{"upload"}}}},
+		"workflow cycle":    {Tasks: []Task{{Name: "a"}}, Workflows: []Workflow{{Name: "w", Steps: []WorkflowStep{{Task: "a", DependsOn: []string{"a"}}}}}},
+		"unreadable tasks":  {},
+	}
+	for name, file := range invalid {
+		a := &fakeAdapter{file: file}
+		if name == "unreadable tasks" {
+			a.fileErr = os.ErrNotExist
+		}
+		c := newFakeEngine(a, previous...)
+
+		if err := c.Reload(); err == nil {
+			t.Errorf("%s: reload should be rejected", name)
+		}
+		if got := c.GetTasks(); !reflect.DeepEqual(got, previous) {
+			t.Errorf("%s: previous tasks should be kept, got %v", name, got)
+		}
+	}
+}
+
+// changingFileAdapter returns a different schedule on every load, as a file
+// edited while the reloads run
+type changingFileAdapter struct {
+	*fakeAdapter
+	loads atomic.Int32
+}
+
+func (a *changingFileAdapter) GetTasksFromPath(tasksPath, format string) (taskFile, error) {
+	minute := a.loads.Add(1) % 60
+	return taskFile{Tasks: []Task{{Name: "backup", Schedule: strconv.Itoa(int(minute)) + " 1 * * *", Command: "echo"}}}, nil
+}
+
+// UnscheduleJob gives the other reloads time to run before rescheduling
+func (a *changingFileAdapter) UnscheduleJob(id string) {
+	a.fakeAdapter.UnscheduleJob(id)
+	time.Sleep(time.Millisecond)
+}
+
+func TestConcurrentReloads(t *testing.T) {
+	a := &changingFileAdapter{fakeAdapter: &fakeAdapter{}}
+	c := &CronTaskEngine{adapter: a, logger: discardLogger}
+	c.tasks = []Task{{Name: "backup", Schedule: "0 1 * * *", Command: "echo"}}
+	if err := c.ScheduleAllTasks(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file watcher, SIGHUP and the admin API may reload at the same time
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			c.Reload()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if len(a.twice) > 0 {
+		t.Errorf("jobs scheduled twice: %v", a.twice)
+	}
+}
+
+func TestWatchTasksFile(t *testing.T) {
+	testDirPath := filepath.Join("test", "uc04_reload_yml_task")
+	if err := os.MkdirAll(testDirPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testDirPath)
+
+	yamlPath := filepath.Join(testDirPath, filePathDefault)
+	write := func(content string) {
+		if err := os.WriteFile(yamlPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`- name: "first"
+  schedule: "0 1 * * *"
+  command: "echo"
+`)
+
+	c := NewCronTaskEngine(Config{
+		testFolderPath: testDirPath,
+		WatchTasksFile: true,
+		WatchInterval:  50 * time.Millisecond,
+	})
+	defer close(c.quit)
+
+	write(`- name: "first"
+  schedule: "0 1 * * *"
+  command: "echo"
+- name: "second"
+  schedule: "0 2 * * *"
+  command: "echo"
+`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(c.GetTasks()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("tasks file change not reloaded, tasks: %v", c.GetTasks())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestWatchTasksFileBrokenAtStartup(t *testing.T) {
+	testDirPath := filepath.Join("test", "uc04_reload_broken_yml_task")
+	if err := os.MkdirAll(testDirPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testDirPath)
+
+	// The tasks file doesn't exist yet, the engine starts without tasks
+	c := NewCronTaskEngine(Config{
+		testFolderPath: testDirPath,
+		WatchTasksFile: true,
+		WatchInterval:  50 * time.Millisecond,
+		Logger:         discardLogger,
+	})
+	defer close(c.quit)
+
+	yamlPath := filepath.Join(testDirPath, filePathDefault)
+	if err := os.WriteFile(yamlPath, []byte(`- name: "first"
+  schedule: "0 1 * * *"
+  command: "echo"
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(c.GetTasks()) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("tasks file created after startup not loaded, tasks: %v", c.GetTasks())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.scheduled {
+		t.Error("tasks loaded after startup should be scheduled")
+	}
+}
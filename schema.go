@@ -0,0 +1,172 @@
+package crontask
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// interpolatedValue matches a value written with a ${VAR} or $VAR reference,
+// checked only once expanded when the tasks file is loaded
+const interpolatedValue = `\$`
+
+// fieldSchemas are the constraints of the fields whose Go type says too little
+var fieldSchemas = map[string]map[string]any{
+	"Task.schedule": {
+		"pattern":  `^$|^\s*[0-9*,/-]+(\s+[0-9*,/-]+){4}\s*$|` + interpolatedValue,
+		"examples": []string{"0 7 * * 1,4", "*/15 * * * *"},
+	},
+	"Workflow.schedule": {
+		"pattern":  `^$|^\s*[0-9*,/-]+(\s+[0-9*,/-]+){4}\s*$|` + interpolatedValue,
+		"examples": []string{"0 2 * * *"},
+	},
+	"Task.timeout": {
+		"pattern":  `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$|` + interpolatedValue,
+		"examples": []string{"30m", "1h30m"},
+	},
+	"Task.max_cpu_time": {
+		"pattern":  `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$|` + interpolatedValue,
+		"examples": []string{"10m"},
+	},
+	"Task.max_memory": {
+		"pattern":  `^[0-9]+([KMGTkmgt][iI]?)?[bB]?$|` + interpolatedValue,
+		"examples": []string{"512M", "2G"},
+	},
+	"Task.ionice": {
+		"pattern":  `^(idle|(best-effort|be|realtime|rt)(:[0-7])?)$|` + interpolatedValue,
+		"examples": []string{"idle", "best-effort:7", "realtime:0"},
+	},
+	"Task.nice": {
+		"minimum": -20,
+		"maximum": 19,
+	},
+	"Task.max_open_files": {
+		"minimum": 1,
+	},
+}
+
+// fieldDescriptions document every key of the tasks file for editors,
+// TestJSONSchemaInSync fails when a new field has none
+var fieldDescriptions = map[string]string{
+	"taskFile.include":   "Other task files to load, relative to this one, globs allowed",
+	"taskFile.defaults":  "Fields every task starts from",
+	"taskFile.templates": "Named sets of fields tasks can extend",
+	"taskFile.tasks":     "Scheduled commands",
+	"taskFile.workflows": "Groups of tasks run in dependency order",
+
+	"Task.name":            "Unique name of the task",
+	"Task.schedule":        "Cron expression minute hour day month weekday, empty to run only on demand or chained",
+	"Task.command":         "Program to execute",
+	"Task.args":            "Arguments of the command, split on spaces",
+	"Task.workdir":         "Working directory of the command, default: the current one",
+	"Task.timeout":         "Maximum run time, the command is killed when it runs longer",
+	"Task.extends":         "Template of templates: this task starts from",
+	"Task.on_success":      "Tasks run after a successful run",
+	"Task.on_failure":      "Tasks run after a failed run",
+	"Task.on_complete":     "Tasks run after every run",
+	"Task.success_codes":   "Exit codes treated as success, default: [0]",
+	"Task.skip_codes":      "Exit codes meaning nothing to do",
+	"Task.fail_on_stderr":  "Mark the run as failed when the command writes to stderr",
+	"Task.success_pattern": "Regular expression that must appear in the output",
+	"Task.user":            "User name or uid to run the command as (native, requires root)",
+	"Task.group":           "Group name or gid, default: the user's primary group",
+	"Task.nice":            "Scheduling priority from -20 to 19 (linux)",
+	"Task.ionice":          "I/O scheduling class: idle, best-effort[:level] or realtime[:level] (linux)",
+	"Task.max_memory":      "Address space limit with an optional K, M, G or T suffix (linux)",
+	"Task.max_cpu_time":    "CPU time before the process is killed (linux)",
+	"Task.max_open_files":  "Open file descriptors limit (linux)",
+	"Task.stdin":           "Text written to the command's standard input",
+	"Task.stdin_file":      "File streamed to the command's standard input",
+	"Task.env":             "Variables added to the command's environment",
+
+	"Workflow.name":     "Unique name of the workflow",
+	"Workflow.schedule": "Cron expression minute hour day month weekday, empty to run only on demand",
+	"Workflow.steps":    "Tasks of the workflow",
+
+	"WorkflowStep.task":       "Name of a loaded task",
+	"WorkflowStep.depends_on": "Tasks of the workflow that must succeed before this one",
+}
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of the tasks file, built
+// from the Go types, so editors and CI can check YAML, JSON and TOML files
+// before deploy eg: crontask schema -o crontasks.schema.json
+func JSONSchema() []byte {
+	defs := map[string]any{
+		"TaskFields":   objectSchema(reflect.TypeOf(Task{})),
+		"Workflow":     objectSchema(reflect.TypeOf(Workflow{})),
+		"WorkflowStep": objectSchema(reflect.TypeOf(WorkflowStep{})),
+		"Task": map[string]any{
+			"$ref":     "#/$defs/TaskFields",
+			"required": []string{"name"},
+		},
+	}
+	defs["Workflow"].(map[string]any)["required"] = []string{"name", "steps"}
+	defs["WorkflowStep"].(map[string]any)["required"] = []string{"task"}
+
+	file := objectSchema(reflect.TypeOf(taskFile{}))
+	properties := file["properties"].(map[string]any)
+	properties["defaults"] = describe(map[string]any{"$ref": "#/$defs/TaskFields"}, "taskFile.defaults")
+	properties["templates"] = describe(map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"$ref": "#/$defs/TaskFields"},
+	}, "taskFile.templates")
+	defs["TaskFile"] = file
+
+	schema := map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "crontask tasks file",
+		"description": "A list of tasks or a mapping with include, defaults, templates, tasks and workflows",
+		"oneOf": []any{
+			map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/Task"}},
+			map[string]any{"$ref": "#/$defs/TaskFile"},
+		},
+		"$defs": defs,
+	}
+
+	out, _ := json.MarshalIndent(schema, "", "  ") // only maps, slices and strings
+	return append(out, '\n')
+}
+
+// objectSchema describes the yaml fields of a struct, unknown keys are errors
+func objectSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	for name, index := range yamlFields(t) {
+		key := t.Name() + "." + name
+		field := typeSchema(t.Field(index).Type)
+		for k, v := range fieldSchemas[key] {
+			field[k] = v
+		}
+		properties[name] = describe(field, key)
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema maps a Go type to its JSON Schema. Numbers and booleans also
+// accept a string with a $VAR reference, expanded when the file is loaded.
+func typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": []string{"integer", "string"}, "pattern": interpolatedValue}
+	case reflect.Bool:
+		return map[string]any{"type": []string{"boolean", "string"}, "pattern": interpolatedValue}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	}
+	panic("crontask: no JSON Schema for " + t.String())
+}
+
+func describe(schema map[string]any, key string) map[string]any {
+	if description, ok := fieldDescriptions[key]; ok {
+		schema["description"] = description
+	}
+	return schema
+}
@@ -0,0 +1,99 @@
+package crontask
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// The committed schema is the one editors and CI use, regenerate it with:
+// go run ./cmd/crontask schema -o crontasks.schema.json
+func TestJSONSchemaInSync(t *testing.T) {
+	committed, err := os.ReadFile("crontasks.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(committed, JSONSchema()) {
+		t.Error("crontasks.schema.json is outdated, run: go run ./cmd/crontask schema -o crontasks.schema.json")
+	}
+
+	// Every key has a description and every description a key
+	keys := make(map[string]bool)
+	for _, typ := range []reflect.Type{reflect.TypeOf(taskFile{}), reflect.TypeOf(Task{}), reflect.TypeOf(Workflow{}), reflect.TypeOf(WorkflowStep{})} {
+		for name := range yamlFields(typ) {
+			keys[typ.Name()+"."+name] = true
+			if _, ok := fieldDescriptions[typ.Name()+"."+name]; !ok {
+				t.Errorf("%s.%s has no description in fieldDescriptions", typ.Name(), name)
+			}
+		}
+	}
+	for key := range fieldDescriptions {
+		if !keys[key] {
+			t.Errorf("fieldDescriptions has %s, a field that doesn't exist", key)
+		}
+	}
+	for key := range fieldSchemas {
+		if !keys[key] {
+			t.Errorf("fieldSchemas has %s, a field that doesn't exist", key)
+		}
+	}
+}
+
+func TestJSONSchemaStructure(t *testing.T) {
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]map[string]any `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(JSONSchema(), &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	task := schema.Defs["TaskFields"].Properties
+	if len(task) != len(yamlFields(reflect.TypeOf(Task{}))) {
+		t.Errorf("TaskFields has %d properties, Task %d yaml fields", len(task), len(yamlFields(reflect.TypeOf(Task{}))))
+	}
+	if got := task["on_failure"]["items"]; !reflect.DeepEqual(got, map[string]any{"type": "string"}) {
+		t.Errorf("on_failure items = %v", got)
+	}
+	if got := schema.Defs["TaskFile"].Properties["tasks"]["items"]; !reflect.DeepEqual(got, map[string]any{"$ref": "#/$defs/Task"}) {
+		t.Errorf("tasks items = %v", got)
+	}
+	if got := schema.Defs["Workflow"].Properties["steps"]["items"]; !reflect.DeepEqual(got, map[string]any{"$ref": "#/$defs/WorkflowStep"}) {
+		t.Errorf("steps items = %v", got)
+	}
+}
+
+func TestJSONSchemaPatterns(t *testing.T) {
+	tests := []struct {
+		field string
+		value string
+		valid bool
+	}{
+		{"Task.schedule", "0 7 * * 1,4", true},
+		{"Task.schedule", "*/15  0-6 * * *", true},
+		{"Task.schedule", "", true},
+		{"Task.schedule", "${BACKUP_SCHEDULE}", true},
+		{"Task.schedule", "0 7 * *", false},
+		{"Task.schedule", "every day", false},
+		{"Task.timeout", "1h30m", true},
+		{"Task.timeout", "1.5s", true},
+		{"Task.timeout", "30", false},
+		{"Task.max_memory", "512M", true},
+		{"Task.max_memory", "2GiB", true},
+		{"Task.max_memory", "lots", false},
+		{"Task.ionice", "best-effort:7", true},
+		{"Task.ionice", "idle", true},
+		{"Task.ionice", "idle:3", false},
+		{"Task.ionice", "realtime:8", false},
+	}
+	for _, tt := range tests {
+		pattern := regexp.MustCompile(fieldSchemas[tt.field]["pattern"].(string))
+		if got := pattern.MatchString(tt.value); got != tt.valid {
+			t.Errorf("%s %q valid = %v, want %v", tt.field, tt.value, got, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package crontask
+
+import (
+	"context"
+	"time"
+)
+
+// killWait is how long Shutdown waits for the runs it killed to be recorded
+const killWait = 2 * time.Second
+
+// processStopper is implemented by the adapters that run commands as child processes
+type processStopper interface {
+	stopScheduler()          // ends the scheduler goroutine
+	signalRunning(kill bool) // SIGTERM, or SIGKILL when kill is set, to the commands in progress
+}
+
+// RunningTask is a task run in progress
+type RunningTask struct {
+	Task     string    // task name
+	RunID    int64     // number of the run in the engine, RunResult.RunID when it ends
+	Workflow string    // workflow name when the run is a workflow step
+	Start    time.Time // when the run started
+}
+
+// startRun registers a run in progress. It returns false once Shutdown was
+// called: no new run starts, follow-ups and workflow steps included.
+func (c *CronTaskEngine) startRun(task Task) (id int64, ok bool) {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+	if c.stopping {
+		return 0, false
+	}
+	if c.running == nil {
+		c.running = make(map[int64]RunningTask)
+	}
+	c.lastRunID++
+	c.running[c.lastRunID] = RunningTask{Task: task.Name, RunID: c.lastRunID, Workflow: task.workflow, Start: time.Now()}
+	c.runWG.Add(1)
+	return c.lastRunID, true
+}
+
+// endRun removes a finished run from the runs in progress
+func (c *CronTaskEngine) endRun(id int64) {
+	c.runMu.Lock()
+	delete(c.running, id)
+	c.runMu.Unlock()
+	c.runWG.Done()
+}
+
+// Running returns the task runs in progress, oldest first
+func (c *CronTaskEngine) Running() []RunningTask {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+	running := make([]RunningTask, 0, len(c.running))
+	for id := int64(1); id <= c.lastRunID && len(running) < len(c.running); id++ {
+		if run, ok := c.running[id]; ok {
+			running = append(running, run)
+		}
+	}
+	return running
+}
+
+// Shutdown stops the engine gracefully: scheduled tasks and workflows are
+// removed, no new run starts and the commands in progress receive SIGTERM
+// and are waited for until they finish or ctx is done, when they are killed
+// with SIGKILL. Then the admin API stops. It can be called again to keep
+// waiting for the function tasks, which can't be killed.
+func (c *CronTaskEngine) Shutdown(ctx context.Context) error {
+	c.runMu.Lock()
+	first := !c.stopping
+	c.stopping = true
+	c.runMu.Unlock()
+
+	if first {
+		c.mu.Lock()
+		scheduled := c.scheduled
+		c.scheduled, c.autoSchedule = false, false
+		c.mu.Unlock()
+		if scheduled {
+			for _, t := range c.GetTasks() {
+				c.adapter.UnscheduleJob("task:" + t.Name)
+			}
+			for _, wf := range c.GetWorkflows() {
+				c.adapter.UnscheduleJob("workflow:" + wf.Name)
+			}
+		}
+		if c.quit != nil {
+			close(c.quit) // stops the file watcher and the reload signal
+		}
+		if stopper, ok := c.adapter.(processStopper); ok {
+			stopper.stopScheduler()
+			stopper.signalRunning(false)
+		}
+		c.logger.Info("shutting down, waiting for the running tasks", "running", len(c.Running()))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.runWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		c.stopAdmin(ctx)
+		c.logger.Info("shutdown complete")
+		return nil
+	case <-ctx.Done():
+		var names []string
+		for _, run := range c.Running() {
+			names = append(names, run.Task)
+		}
+		killed := false
+		if stopper, ok := c.adapter.(processStopper); ok {
+			stopper.signalRunning(true)
+			select {
+			case <-done:
+				killed = true
+			case <-time.After(killWait): // function tasks can't be killed
+			}
+		}
+		c.stopAdmin(ctx)
+		if killed {
+			return newErr("shutdown deadline reached, killed the running tasks:", names)
+		}
+		return newErr("shutdown interrupted, tasks still running:", names)
+	}
+}
+
+// LogStatus writes to the log the loaded tasks with their next run and last
+// result, and the runs in progress eg: on SIGUSR1
+func (c *CronTaskEngine) LogStatus() {
+	tasks, workflows, running := c.GetTasks(), c.GetWorkflows(), c.Running()
+	c.logger.Info("status", "tasks", len(tasks), "workflows", len(workflows), "running", len(running))
+
+	now := time.Now()
+	for _, run := range running {
+		attrs := []any{"task", run.Task, "run_id", run.RunID, "started", run.Start, "elapsed", now.Sub(run.Start).Round(time.Second)}
+		if run.Workflow != "" {
+			attrs = append(attrs, "workflow", run.Workflow)
+		}
+		c.logger.Info("running", attrs...)
+	}
+
+	last := make(map[string]RunResult)
+	for _, result := range c.History() {
+		last[result.Task] = result
+	}
+	for _, t := range tasks {
+		next := "on demand"
+		if runs, err := NextRuns(t.Schedule, now, 1); err == nil && len(runs) > 0 {
+			next = runs[0].Format("2006-01-02 15:04")
+		}
+		lastRun := "never run"
+		if result, ok := last[t.Name]; ok {
+			lastRun = string(result.Status) + " at " + result.Start.Format("2006-01-02 15:04:05")
+		}
+		c.logger.Info("task status", "task", t.Name, "next_run", next, "last_run", lastRun)
+	}
+}
@@ -0,0 +1,72 @@
+package crontask
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForRunningTasks(t *testing.T) {
+	a := &fakeAdapter{release: make(chan struct{})}
+	c := newFakeEngine(a,
+		Task{Name: "backup", Schedule: "0 2 * * *", Command: "backup", OnSuccess: []string{"upload"}},
+		Task{Name: "upload", Command: "upload"},
+	)
+	c.quit = make(chan struct{})
+	if err := c.ScheduleAllTasks(); err != nil {
+		t.Fatal(err)
+	}
+
+	finished := make(chan RunResult)
+	go func() {
+		result, _ := c.RunTask("backup")
+		finished <- result
+	}()
+	waitFor(t, func() bool { return len(c.Running()) == 1 })
+	if run := c.Running()[0]; run.Task != "backup" || run.Start.IsZero() {
+		t.Errorf("running = %+v", run)
+	}
+
+	// The run in progress is not interrupted
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := c.Shutdown(ctx)
+	if err == nil || !strings.Contains(err.Error(), "backup") {
+		t.Fatalf("Shutdown while running = %v, want an error naming backup", err)
+	}
+	if len(a.jobs) != 0 {
+		t.Errorf("jobs still scheduled after Shutdown: %v", a.jobs)
+	}
+	select {
+	case <-c.quit:
+	default:
+		t.Error("quit not closed")
+	}
+
+	close(a.release)
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if result := <-finished; result.Status != StatusSuccess {
+		t.Errorf("backup = %+v", result)
+	}
+
+	// Neither the follow-up nor new runs start once shutting down
+	if names := a.names(); len(names) != 1 {
+		t.Errorf("executed %v, want only backup", names)
+	}
+	if _, err := c.RunTask("upload"); err == nil || !strings.Contains(err.Error(), "shutting down") {
+		t.Errorf("RunTask after Shutdown = %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal("condition not met in time")
+}
@@ -0,0 +1,32 @@
+//go:build !wasm && !windows
+
+package crontask
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReload reports every SIGHUP received by the process
+func notifyReload(quit <-chan struct{}) (<-chan struct{}, error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	reloads := make(chan struct{}, 1)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-quit:
+				return
+			case <-signals:
+				select {
+				case reloads <- struct{}{}:
+				default: // a reload is already pending
+				}
+			}
+		}
+	}()
+	return reloads, nil
+}
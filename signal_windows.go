@@ -0,0 +1,8 @@
+//go:build !wasm && windows
+
+package crontask
+
+// notifyReload is not available on windows, there is no SIGHUP
+func notifyReload(quit <-chan struct{}) (<-chan struct{}, error) {
+	return nil, newErr("SIGHUP reload is not supported on windows")
+}
@@ -0,0 +1,207 @@
+package crontask
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// Firing is a run of a simulated timeline
+type Firing struct {
+	Start      time.Time // when the run would start
+	End        time.Time // when it is assumed to finish
+	Task       string    // task name
+	Workflow   string    // workflow name when the run is a workflow step
+	After      string    // task this follow-up runs after, if any
+	Overlap    bool      // a previous run of the same task is still running, both run at once
+	Concurrent int       // runs in progress when this one starts, itself included
+}
+
+// SimulateOptions are the assumptions of a simulation
+type SimulateOptions struct {
+	Duration  time.Duration            // run time of every task, default: 1m
+	Durations map[string]time.Duration // run time by task name eg: measured ones, overrides Duration
+}
+
+// simulateLimit stops the simulation of a window too large to be read
+const simulateLimit = 100000
+
+// defaultSimulatedDuration is the run time of a task when nothing else is known
+const defaultSimulatedDuration = time.Minute
+
+// Simulate returns, ordered by start, the runs the scheduler would start from
+// from until to without executing anything. Every run is assumed to succeed
+// and last its SimulateOptions duration: on_success and on_complete follow-ups
+// start one after the other when it ends, like workflow steps when their
+// dependencies end. The engine has no concurrency limit and doesn't skip a run
+// whose previous one is still going, Overlap and Concurrent show where it happens.
+func Simulate(tasks []Task, workflows []Workflow, from, to time.Time, opts SimulateOptions) ([]Firing, error) {
+	s := &simulation{tasks: tasks, opts: opts}
+
+	start := from.Truncate(time.Minute)
+	if start.Before(from) {
+		start = start.Add(time.Minute)
+	}
+	for _, t := range tasks {
+		if t.Schedule == "" {
+			continue
+		}
+		j, err := parseSchedule(t.Schedule)
+		if err != nil {
+			return nil, newErr("task", t.Name, "invalid schedule:", err)
+		}
+		for run, ok := j.nextMatch(start, to); ok; run, ok = j.nextMatch(run.Add(time.Minute), to) {
+			s.run(t, run, "", "", nil)
+			if len(s.firings) > simulateLimit {
+				return nil, newErr("more than", simulateLimit, "runs to simulate, use a shorter window")
+			}
+		}
+	}
+	for _, wf := range workflows {
+		if wf.Schedule == "" {
+			continue
+		}
+		j, err := parseSchedule(wf.Schedule)
+		if err != nil {
+			return nil, newErr("workflow", wf.Name, "invalid schedule:", err)
+		}
+		for run, ok := j.nextMatch(start, to); ok; run, ok = j.nextMatch(run.Add(time.Minute), to) {
+			s.workflow(wf, run)
+			if len(s.firings) > simulateLimit {
+				return nil, newErr("more than", simulateLimit, "runs to simulate, use a shorter window")
+			}
+		}
+	}
+
+	s.timeline()
+	return s.firings, nil
+}
+
+// simulation collects the runs of Simulate
+type simulation struct {
+	tasks   []Task
+	opts    SimulateOptions
+	firings []Firing
+}
+
+func (s *simulation) duration(task string) time.Duration {
+	if d := s.opts.Durations[task]; d > 0 {
+		return d
+	}
+	if s.opts.Duration > 0 {
+		return s.opts.Duration
+	}
+	return defaultSimulatedDuration
+}
+
+// run adds a run and its follow-ups, it returns when the last of them ends
+func (s *simulation) run(task Task, start time.Time, workflow, after string, chain []string) time.Time {
+	end := start.Add(s.duration(task.Name))
+	s.firings = append(s.firings, Firing{Start: start, End: end, Task: task.Name, Workflow: workflow, After: after})
+
+	// The follow-ups run one after the other, as in runFollowUps
+	chain = append(chain, task.Name)
+	for _, name := range task.followUps(StatusSuccess) {
+		next, ok := taskByName(s.tasks, name)
+		if !ok || slices.Contains(chain, name) {
+			continue
+		}
+		end = s.run(next, end, workflow, task.Name, chain)
+	}
+	return end
+}
+
+// workflow adds the steps of a workflow, each starting when its dependencies end
+func (s *simulation) workflow(wf Workflow, start time.Time) {
+	ends := make(map[string]time.Time, len(wf.Steps))
+	for len(ends) < len(wf.Steps) {
+		progress := false
+		for _, step := range wf.Steps {
+			if _, done := ends[step.Task]; done {
+				continue
+			}
+			stepStart, ready := start, true
+			for _, dep := range step.DependsOn {
+				end, done := ends[dep]
+				if !done {
+					ready = false
+					break
+				}
+				if end.After(stepStart) {
+					stepStart = end
+				}
+			}
+			if !ready {
+				continue
+			}
+			task, _ := taskByName(s.tasks, step.Task)
+			task.Name = step.Task
+			ends[step.Task] = s.run(task, stepStart, wf.Name, "", nil)
+			progress = true
+		}
+		if !progress {
+			return // a cycle, rejected when the tasks are loaded
+		}
+	}
+}
+
+// timeline sorts the runs and marks the overlaps and the concurrent runs
+func (s *simulation) timeline() {
+	sort.SliceStable(s.firings, func(a, b int) bool {
+		fa, fb := s.firings[a], s.firings[b]
+		if !fa.Start.Equal(fb.Start) {
+			return fa.Start.Before(fb.Start)
+		}
+		return fa.Task < fb.Task
+	})
+
+	var active []time.Time // ends of the runs in progress
+	lastEnd := make(map[string]time.Time)
+	for i := 0; i < len(s.firings); {
+		// The runs starting at the same minute are concurrent with each other
+		group := i
+		for group < len(s.firings) && s.firings[group].Start.Equal(s.firings[i].Start) {
+			group++
+		}
+
+		now := s.firings[i].Start
+		kept := active[:0]
+		for _, end := range active {
+			if end.After(now) {
+				kept = append(kept, end)
+			}
+		}
+		active = kept
+
+		for k := i; k < group; k++ {
+			f := &s.firings[k]
+			f.Overlap = lastEnd[f.Task].After(now)
+			if f.End.After(lastEnd[f.Task]) {
+				lastEnd[f.Task] = f.End
+			}
+			active = append(active, f.End)
+		}
+		for k := i; k < group; k++ {
+			s.firings[k].Concurrent = len(active)
+		}
+		i = group
+	}
+}
+
+// Simulate runs Simulate over the loaded tasks and workflows, each task
+// lasting its average duration in History, if it ran, or one minute
+func (c *CronTaskEngine) Simulate(from, to time.Time) ([]Firing, error) {
+	total := make(map[string]time.Duration)
+	runs := make(map[string]int)
+	for _, result := range c.History() {
+		if result.Duration > 0 {
+			total[result.Task] += result.Duration
+			runs[result.Task]++
+		}
+	}
+	durations := make(map[string]time.Duration, len(total))
+	for name, d := range total {
+		durations[name] = d / time.Duration(runs[name])
+	}
+	return Simulate(c.GetTasks(), c.GetWorkflows(), from, to, SimulateOptions{Durations: durations})
+}
@@ -0,0 +1,67 @@
+package crontask
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimulate(t *testing.T) {
+	tasks := []Task{
+		{Name: "backup", Schedule: "0 */2 * * *", Command: "backup", OnSuccess: []string{"upload"}},
+		{Name: "upload", Command: "upload"},
+		{Name: "sync", Schedule: "*/30 * * * *", Command: "sync"},
+		{Name: "extract", Command: "extract"},
+		{Name: "load", Command: "load"},
+	}
+	workflows := []Workflow{
+		{Name: "etl", Schedule: "0 1 * * *", Steps: []WorkflowStep{{Task: "load", DependsOn: []string{"extract"}}, {Task: "extract"}}},
+	}
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	firings, err := Simulate(tasks, workflows, from, from.Add(2*time.Hour), SimulateOptions{
+		Duration:  10 * time.Minute,
+		Durations: map[string]time.Duration{"sync": 45 * time.Minute},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(h, m int) time.Time { return from.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+	want := []Firing{
+		{Start: at(0, 0), End: at(0, 10), Task: "backup", Concurrent: 2},
+		{Start: at(0, 0), End: at(0, 45), Task: "sync", Concurrent: 2},
+		{Start: at(0, 10), End: at(0, 20), Task: "upload", After: "backup", Concurrent: 2},
+		{Start: at(0, 30), End: at(1, 15), Task: "sync", Overlap: true, Concurrent: 2},
+		{Start: at(1, 0), End: at(1, 10), Task: "extract", Workflow: "etl", Concurrent: 3},
+		{Start: at(1, 0), End: at(1, 45), Task: "sync", Overlap: true, Concurrent: 3},
+		{Start: at(1, 10), End: at(1, 20), Task: "load", Workflow: "etl", Concurrent: 3},
+		{Start: at(1, 30), End: at(2, 15), Task: "sync", Overlap: true, Concurrent: 2},
+	}
+	if len(firings) != len(want) {
+		t.Fatalf("got %d firings, want %d:\n%+v", len(firings), len(want), firings)
+	}
+	for i := range want {
+		got := firings[i]
+		if !got.Start.Equal(want[i].Start) || !got.End.Equal(want[i].End) || got.Task != want[i].Task || got.Workflow != want[i].Workflow ||
+			got.After != want[i].After || got.Overlap != want[i].Overlap || got.Concurrent != want[i].Concurrent {
+			t.Errorf("firing %d:\n got  %+v\n want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestSimulateLimits(t *testing.T) {
+	tasks := []Task{{Name: "tick", Schedule: "* * * * *", Command: "true"}}
+	from := time.Date(2026, 3, 2, 0, 0, 30, 0, time.UTC)
+
+	firings, err := Simulate(tasks, nil, from, from.Add(3*time.Minute), SimulateOptions{Duration: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// from is rounded up to the next minute and to is excluded
+	if len(firings) != 3 || firings[0].Start.Minute() != 1 || firings[0].Overlap {
+		t.Errorf("firings = %+v", firings)
+	}
+
+	if _, err := Simulate(tasks, nil, from, from.AddDate(1, 0, 0), SimulateOptions{}); err == nil {
+		t.Error("expected an error for a year of every minute runs")
+	}
+}
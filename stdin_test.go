@@ -0,0 +1,42 @@
+//go:build !wasm && !windows
+
+package crontask
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTaskStdin(t *testing.T) {
+	a := &nativeAdapter{}
+	const config = `{"mode": "full"}`
+
+	result, err := a.ExecuteCmd(Task{Name: "inline_stdin", Command: "cat", Stdin: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Output != config {
+		t.Errorf("stdin output = %q, want %q", result.Output, config)
+	}
+
+	stdinFile := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(stdinFile, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = a.ExecuteCmd(Task{Name: "file_stdin", Command: "cat", StdinFile: stdinFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Output != config {
+		t.Errorf("stdin_file output = %q, want %q", result.Output, config)
+	}
+
+	if _, err := a.ExecuteCmd(Task{Name: "missing_stdin", Command: "cat", StdinFile: stdinFile + ".missing"}); err == nil {
+		t.Error("missing stdin_file should fail the run")
+	}
+
+	if err := checkNativeTask(Task{Name: "both", Stdin: "a", StdinFile: stdinFile}); err == nil {
+		t.Error("stdin and stdin_file together should be rejected at load time")
+	}
+}
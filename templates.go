@@ -0,0 +1,152 @@
+package crontask
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// mergeTemplates adds the defaults: and templates: of one file to the merged
+// task set. Both are shared by all the files, so each may only be defined once.
+func mergeTemplates(merged *taskFile, file taskFile) ValidationErrors {
+	var problems ValidationErrors
+	at := func(path, message string) {
+		p := file.positions[path]
+		problems = append(problems, ValidationError{File: p.file, Line: p.line, Column: p.column, Message: message})
+	}
+	where := func(path string) string {
+		p := merged.positions[path]
+		return p.file + ":" + strconv.Itoa(p.line)
+	}
+	// The positions of a rejected definition must not replace the first one
+	forget := func(path string) {
+		for key := range file.positions {
+			if key == path || strings.HasPrefix(key, path+".") {
+				delete(file.positions, key)
+			}
+		}
+	}
+
+	if _, ok := file.positions["defaults"]; ok {
+		if _, dup := merged.positions["defaults"]; dup {
+			at("defaults", "defaults already defined in "+where("defaults"))
+			forget("defaults")
+		} else {
+			merged.Defaults = file.Defaults
+		}
+	}
+
+	for name, t := range file.Templates {
+		path := "templates." + name
+		if _, dup := merged.Templates[name]; dup {
+			at(path, "duplicate template "+strconv.Quote(name)+", first defined in "+where(path))
+			forget(path)
+			continue
+		}
+		if merged.Templates == nil {
+			merged.Templates = make(map[string]Task)
+		}
+		merged.Templates[name] = t
+	}
+	return problems
+}
+
+// resolveTemplates builds every task from the defaults, then the chain of
+// templates it extends and finally its own fields. A field set at a later
+// level replaces the earlier one, except env: whose variables are merged.
+func resolveTemplates(file *taskFile) ValidationErrors {
+	v := &taskValidator{file: *file}
+
+	if _, ok := file.positions["defaults.name"]; ok {
+		v.at("defaults.name", "", "defaults can't set the task name")
+	}
+	if file.Defaults.Extends != "" {
+		v.at("defaults.extends", "", "defaults can't extend a template")
+	}
+	for name := range file.Templates {
+		if _, ok := file.positions["templates."+name+".name"]; ok {
+			v.at("templates."+name+".name", "", "template "+strconv.Quote(name)+" can't set the task name")
+		}
+	}
+
+	_, hasDefaults := file.positions["defaults"]
+	for i, t := range file.Tasks {
+		path := "tasks[" + strconv.Itoa(i) + "]"
+		chain, err := templateChain(file.Templates, t.Extends)
+		if err != nil {
+			v.at(path+".extends", t.source, "task "+strconv.Quote(t.Name)+" "+err.Error())
+			continue
+		}
+		if !hasDefaults && len(chain) == 0 {
+			continue
+		}
+
+		// From the most specific level to the most generic, each one fills what is still unset
+		resolved, set := Task{}, make(map[string]bool)
+		applyTaskFields(&resolved, t, path, path, file.positions, set)
+		for _, name := range chain {
+			applyTaskFields(&resolved, file.Templates[name], "templates."+name, path, file.positions, set)
+		}
+		if hasDefaults {
+			applyTaskFields(&resolved, file.Defaults, "defaults", path, file.positions, set)
+		}
+		resolved.Name, resolved.Extends = t.Name, t.Extends
+		resolved.source, resolved.workflow, resolved.env = t.source, t.workflow, t.env
+		file.Tasks[i] = resolved
+	}
+	return v.problems
+}
+
+// templateChain returns the templates a task extends, the closest first
+func templateChain(templates map[string]Task, extends string) ([]string, error) {
+	var chain []string
+	for name := extends; name != ""; name = templates[name].Extends {
+		if _, ok := templates[name]; !ok {
+			return nil, newErr("extends unknown template", strconv.Quote(name))
+		}
+		for _, seen := range chain {
+			if seen == name {
+				return nil, newErr("template cycle:", strings.Join(append(chain, name), " -> "))
+			}
+		}
+		chain = append(chain, name)
+	}
+	return chain, nil
+}
+
+// applyTaskFields copies onto dst the fields src sets that are not set yet.
+// A field is set when written in the file at the from path, even if zero, or
+// when not zero. The env: variables are merged. The positions of inherited
+// fields point to the template line that set them.
+func applyTaskFields(dst *Task, src Task, from, to string, positions map[string]position, set map[string]bool) {
+	dv, sv := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src)
+	for name, index := range yamlFields(sv.Type()) {
+		p, written := positions[from+"."+name]
+		field := sv.Field(index)
+		if !written && field.IsZero() {
+			continue
+		}
+
+		switch {
+		case field.Kind() == reflect.Map:
+			// The variables already set win
+			merged := reflect.MakeMap(field.Type())
+			for _, m := range []reflect.Value{field, dv.Field(index)} {
+				for _, k := range m.MapKeys() {
+					merged.SetMapIndex(k, m.MapIndex(k))
+				}
+			}
+			dv.Field(index).Set(merged)
+		case set[name]:
+			continue
+		default:
+			dv.Field(index).Set(field)
+		}
+
+		set[name] = true
+		if _, own := positions[to+"."+name]; written && !own {
+			positions[to+"."+name] = p
+		}
+	}
+	dst.secrets = append(dst.secrets, src.secrets...)
+}
@@ -0,0 +1,100 @@
+//go:build !wasm
+
+package crontask
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultsAndTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFiles(t, dir, map[string]string{
+		"crontasks.yml": `include: ["teams/*.yml"]
+defaults:
+  timeout: "30m"
+  workdir: "/srv/app"
+  env: {APP_ENV: "production", TZ: "UTC"}
+templates:
+  db_job:
+    nice: 5
+    fail_on_stderr: true
+    env: {PGHOST: "db1"}
+  nightly_db_job:
+    extends: db_job
+    schedule: "0 2 * * *"
+    env: {PGHOST: "db2"}
+tasks:
+  - name: "backup"
+    extends: nightly_db_job
+    command: "pg_dump"
+    fail_on_stderr: false
+    env: {TZ: "Europe/Madrid"}
+  - name: "plain"
+    command: "true"
+    timeout: "1m"
+`,
+		"teams/reports.yml": `- name: "report"
+  extends: db_job
+  command: "psql"
+`,
+	})
+	a := &nativeAdapter{}
+
+	file, err := a.GetTasksFromPath(filepath.Join(dir, "crontasks.yml"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Task{
+		{Name: "backup", Extends: "nightly_db_job", Schedule: "0 2 * * *", Command: "pg_dump", Timeout: "30m", WorkDir: "/srv/app",
+			Nice: 5, Env: map[string]string{"APP_ENV": "production", "TZ": "Europe/Madrid", "PGHOST": "db2"}},
+		{Name: "plain", Command: "true", Timeout: "1m", WorkDir: "/srv/app",
+			Env: map[string]string{"APP_ENV": "production", "TZ": "UTC"}},
+		{Name: "report", Extends: "db_job", Command: "psql", Timeout: "30m", WorkDir: "/srv/app",
+			Nice: 5, FailOnStderr: true, Env: map[string]string{"APP_ENV": "production", "TZ": "UTC", "PGHOST": "db1"}},
+	}
+	for i := range file.Tasks {
+		file.Tasks[i].source = ""
+	}
+	if !reflect.DeepEqual(file.Tasks, want) {
+		t.Errorf("resolved tasks\n got %+v\nwant %+v", file.Tasks, want)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFiles(t, dir, map[string]string{
+		"crontasks.yml": `templates:
+  slow:
+    timeout: "forever"
+  a: {extends: b}
+  b: {extends: a}
+tasks:
+  - {name: "uses_slow", command: "true", extends: slow}
+  - {name: "cycle", command: "true", extends: a}
+  - {name: "unknown", command: "true", extends: missing}
+`,
+		"other.yml": `templates:
+  slow: {timeout: "1m"}
+`,
+	})
+	a := &nativeAdapter{}
+
+	_, err := a.GetTasksFromPath(filepath.Join(dir, "*.yml"), "")
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		`crontasks.yml:3:14: task "uses_slow" invalid timeout "forever"`,
+		`crontasks.yml:8:47: task "cycle" template cycle: a -> b -> a`,
+		`crontasks.yml:9:49: task "unknown" extends unknown template "missing"`,
+		`other.yml:2:9: duplicate template "slow", first defined in ` + filepath.Join(dir, "crontasks.yml") + ":3",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing error %s in:\n%v", want, err)
+		}
+	}
+}
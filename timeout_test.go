@@ -0,0 +1,61 @@
+//go:build !wasm && !windows
+
+package crontask
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskTimeout(t *testing.T) {
+	a := &nativeAdapter{}
+
+	start := time.Now()
+	result, err := a.ExecuteCmd(Task{Name: "slow", Command: "sleep", Args: "5", Timeout: "100ms"})
+	if err == nil || result.Status != StatusFailed || !result.TimedOut || result.Reason != "timed out after 100ms" {
+		t.Errorf("expected a timeout, got %+v %v", result, err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("the command was not killed on timeout")
+	}
+
+	if _, err := a.ExecuteCmd(Task{Name: "fast", Command: "true", Timeout: "5s"}); err != nil {
+		t.Errorf("fast command should not time out: %v", err)
+	}
+}
+
+func TestTaskWorkDir(t *testing.T) {
+	a := &nativeAdapter{}
+	dir := t.TempDir()
+
+	result, err := a.ExecuteCmd(Task{Name: "pwd", Command: "pwd", WorkDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(result.Output) != dir {
+		t.Errorf("ran in %q, want %q", strings.TrimSpace(result.Output), dir)
+	}
+}
+
+func TestFollowUpReadsPreviousResult(t *testing.T) {
+	data := `- name: "sync"
+  command: "sh"
+  args: "-c 'exit 3'"
+  on_failure: ["report"]
+- name: "report"
+  command: "sh"
+  args: "-c 'echo $CRONTASK_PREV_TASK exited ${CRONTASK_PREV_EXIT}'"
+`
+	file, err := parseTaskData([]byte(data), "tasks.yml", FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &CronTaskEngine{adapter: &nativeAdapter{}, tasks: file.Tasks, logger: discardLogger}
+
+	c.ExecuteTask("sync")
+	history := c.History()
+	if len(history) != 2 || strings.TrimSpace(history[1].Output) != "sync exited 3" {
+		t.Errorf("follow-up history %+v", history)
+	}
+}
@@ -0,0 +1,259 @@
+package crontask
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationError is a problem found in a tasks file
+type ValidationError struct {
+	File    string // eg: "/etc/crontask.d/backup.yml"
+	Line    int    // 1-based, 0 when unknown
+	Column  int    // 1-based, 0 when unknown
+	Message string // eg: `unknown key "schedul", did you mean "schedule"?`
+}
+
+func (e ValidationError) Error() string {
+	location := e.File
+	if e.Line > 0 {
+		location += ":" + strconv.Itoa(e.Line) + ":" + strconv.Itoa(e.Column)
+	}
+	if location == "" {
+		return e.Message
+	}
+	return location + ": " + e.Message
+}
+
+// ValidationErrors are all the problems found in a task set, one per line
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = e.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// err returns nil when there are no problems, avoiding a non nil error interface
+func (errs ValidationErrors) err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].File != errs[j].File {
+			return errs[i].File < errs[j].File
+		}
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+	return errs
+}
+
+// Validate loads the tasks at tasksPath (a file, directory or glob) and returns
+// every problem found: syntax errors, unknown keys, duplicate names, empty
+// commands, bad schedules and broken references between tasks. The returned
+// error is a ValidationErrors when the files could be read.
+func Validate(tasksPath string) error {
+	_, _, err := LoadTasks(tasksPath, "")
+	return err
+}
+
+// LoadTasks reads and validates the tasks and workflows at tasksPath without
+// creating an engine, format is one of Config.Format, "" to detect it
+func LoadTasks(tasksPath, format string) ([]Task, []Workflow, error) {
+	file, err := newCronAdapter(discardLogger).GetTasksFromPath(tasksPath, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file.Tasks, file.Workflows, nil
+}
+
+// taskValidator collects the problems of a merged task set
+type taskValidator struct {
+	file     taskFile
+	problems ValidationErrors
+}
+
+// at reports a problem at the position recorded for path, falling back
+// to the source file of the task when the format has no positions
+func (v *taskValidator) at(path, source string, message string) {
+	p, ok := v.file.positions[path]
+	if !ok {
+		p.file = source
+	}
+	v.problems = append(v.problems, ValidationError{File: p.file, Line: p.line, Column: p.column, Message: message})
+}
+
+func (v *taskValidator) where(path string) string {
+	p := v.file.positions[path]
+	if p.line == 0 {
+		return p.file
+	}
+	return p.file + ":" + strconv.Itoa(p.line)
+}
+
+// validateTaskFile checks a merged task set and compiles the success_pattern
+// of its tasks once for all their runs. checkTask adds the checks only an
+// adapter can do, eg: the user: field exists on this host; it may be nil.
+// The compiled patterns are written into file.Tasks, a slice the caller owns.
+func validateTaskFile(file taskFile, checkTask func(Task) error) ValidationErrors {
+	v := &taskValidator{file: file}
+
+	firstTask := make(map[string]string) // name => path of its first definition
+	for i, t := range file.Tasks {
+		path := "tasks[" + strconv.Itoa(i) + "]"
+
+		switch first, dup := firstTask[t.Name]; {
+		case t.Name == "":
+			v.at(path, t.source, "task without name")
+		case dup:
+			v.at(path+".name", t.source, "duplicate task name "+strconv.Quote(t.Name)+", first defined in "+v.where(first+".name"))
+		default:
+			firstTask[t.Name] = path
+		}
+
+		label := "task " + strconv.Quote(t.Name)
+		if strings.TrimSpace(t.Command) == "" {
+			v.at(path, t.source, label+" has no command")
+		}
+		if t.Schedule != "" {
+			if _, err := parseSchedule(t.Schedule); err != nil {
+				v.at(path+".schedule", t.source, label+" invalid schedule "+strconv.Quote(t.Schedule)+": "+err.Error())
+			}
+		}
+		if t.Timeout != "" {
+			if d, err := time.ParseDuration(t.Timeout); err != nil || d <= 0 {
+				v.at(path+".timeout", t.source, label+" invalid timeout "+strconv.Quote(t.Timeout)+", expected a duration eg: \"30m\"")
+			}
+		}
+		if t.SuccessPattern != "" {
+			re, err := regexp.Compile(t.SuccessPattern)
+			if err != nil {
+				v.at(path+".success_pattern", t.source, label+" invalid success_pattern: "+err.Error())
+			}
+			file.Tasks[i].successRe = re
+		}
+		if _, err := t.resourceLimits(); err != nil {
+			v.at(path, t.source, err.Error())
+		}
+		if checkTask != nil {
+			if err := checkTask(t); err != nil {
+				v.at(path, t.source, err.Error())
+			}
+		}
+	}
+
+	// Follow-ups may reference tasks defined later or in other files
+	for i, t := range file.Tasks {
+		path := "tasks[" + strconv.Itoa(i) + "]"
+		followUps := []struct {
+			key   string
+			names []string
+		}{{"on_success", t.OnSuccess}, {"on_failure", t.OnFailure}, {"on_complete", t.OnComplete}}
+		for _, f := range followUps {
+			key := f.key
+			for j, name := range f.names {
+				if _, ok := firstTask[name]; !ok {
+					v.at(path+"."+key+"["+strconv.Itoa(j)+"]", t.source, "task "+strconv.Quote(t.Name)+" "+key+" references unknown task "+strconv.Quote(name))
+				}
+			}
+		}
+	}
+
+	firstWorkflow := make(map[string]string)
+	for i, wf := range file.Workflows {
+		path := "workflows[" + strconv.Itoa(i) + "]"
+		label := "workflow " + strconv.Quote(wf.Name)
+
+		switch first, dup := firstWorkflow[wf.Name]; {
+		case wf.Name == "":
+			v.at(path, "", "workflow without name")
+		case dup:
+			v.at(path+".name", "", "duplicate workflow name "+strconv.Quote(wf.Name)+", first defined in "+v.where(first+".name"))
+		default:
+			firstWorkflow[wf.Name] = path
+		}
+
+		if wf.Schedule != "" {
+			if _, err := parseSchedule(wf.Schedule); err != nil {
+				v.at(path+".schedule", "", label+" invalid schedule "+strconv.Quote(wf.Schedule)+": "+err.Error())
+			}
+		}
+		v.checkWorkflowSteps(wf, path, label, firstTask)
+	}
+
+	return v.problems
+}
+
+// checkWorkflowSteps validates the steps of a workflow: every step must name a
+// known task once, every dependency must be a step of the same workflow and the
+// dependencies must not form a cycle.
+func (v *taskValidator) checkWorkflowSteps(wf Workflow, path, label string, tasks map[string]string) {
+	if len(wf.Steps) == 0 {
+		v.at(path, "", label+" has no steps")
+		return
+	}
+
+	steps := make(map[string]WorkflowStep, len(wf.Steps))
+	for i, step := range wf.Steps {
+		stepPath := path + ".steps[" + strconv.Itoa(i) + "]"
+		if _, ok := tasks[step.Task]; !ok {
+			v.at(stepPath+".task", "", label+" references unknown task "+strconv.Quote(step.Task))
+		}
+		if _, dup := steps[step.Task]; dup {
+			v.at(stepPath+".task", "", label+" has task "+strconv.Quote(step.Task)+" more than once")
+		}
+		steps[step.Task] = step
+	}
+
+	for i, step := range wf.Steps {
+		for j, dep := range step.DependsOn {
+			if _, ok := steps[dep]; !ok {
+				depPath := path + ".steps[" + strconv.Itoa(i) + "].depends_on[" + strconv.Itoa(j) + "]"
+				v.at(depPath, "", label+" step "+strconv.Quote(step.Task)+" depends on "+strconv.Quote(dep)+" which is not a step of the workflow")
+			}
+		}
+	}
+
+	// Depth first search, a step found again while still on the path closes a cycle
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(steps))
+	var stack []string
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting:
+			cycle := append(slices.Clone(stack[slices.Index(stack, name):]), name)
+			v.at(path, "", label+" has a dependency cycle: "+strings.Join(cycle, " -> "))
+			return false
+		case visited:
+			return true
+		}
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range steps[name].DependsOn {
+			if _, ok := steps[dep]; ok && !visit(dep) {
+				return false
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		return true
+	}
+	for _, step := range wf.Steps {
+		if !visit(step.Task) {
+			return
+		}
+	}
+}
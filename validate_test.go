@@ -0,0 +1,148 @@
+//go:build !wasm
+
+package crontask
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFiles(t, dir, map[string]string{
+		"crontasks.yml": `- name: "backup"
+  schedul: "0 1 * * *"
+  command: "echo"
+- name: "report"
+  schedule: "0 25 * * *"
+  command: ""
+  on_success: ["upload"]
+- name: "backup"
+  schedule: "0 2 * * *"
+  command: "echo"
+  nice: "low"
+`,
+	})
+
+	err := Validate(filepath.Join(dir, "crontasks.yml"))
+	var problems ValidationErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+
+	want := []struct {
+		line, column int
+		message      string
+	}{
+		{2, 3, `unknown key "schedul", did you mean "schedule"?`},
+		{4, 3, `task "report" has no command`},
+		{5, 13, `invalid schedule`},
+		{7, 16, `references unknown task "upload"`},
+		{8, 9, `duplicate task name "backup", first defined in`},
+		{11, 9, `nice: expected an integer`},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d:\n%v", len(problems), len(want), err)
+	}
+	for i, w := range want {
+		p := problems[i]
+		if p.Line != w.line || p.Column != w.column || !strings.Contains(p.Message, w.message) {
+			t.Errorf("problem %d = %d:%d %q, want %d:%d containing %q", i, p.Line, p.Column, p.Message, w.line, w.column, w.message)
+		}
+		if !strings.HasPrefix(p.Error(), filepath.Join(dir, "crontasks.yml")+":") {
+			t.Errorf("problem %q should start with the file name", p.Error())
+		}
+	}
+}
+
+func TestValidateRejectsUnknownTopLevelKey(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFiles(t, dir, map[string]string{
+		"crontasks.yml": `taks:
+  - name: "backup"
+    schedule: "0 1 * * *"
+    command: "echo"
+`,
+	})
+
+	err := Validate(filepath.Join(dir, "crontasks.yml"))
+	if err == nil || !strings.Contains(err.Error(), `:1:1: unknown key "taks", did you mean "tasks"?`) {
+		t.Errorf("expected unknown key error instead of a silent fallback, got %v", err)
+	}
+}
+
+func TestValidateRejectsInvalidSteps(t *testing.T) {
+	for _, schedule := range []string{"*/0 * * * *", "0-5/0 * * * *", "0 0 */0 * *", "*/ * * * *", "*/x * * * *"} {
+		dir := t.TempDir()
+		writeTaskFiles(t, dir, map[string]string{
+			"crontasks.yml": `- name: "backup"
+  schedule: "` + schedule + `"
+  command: "echo"
+`,
+		})
+
+		// Used to loop forever on a zero step
+		err := Validate(filepath.Join(dir, "crontasks.yml"))
+		if err == nil || !strings.Contains(err.Error(), "invalid schedule") {
+			t.Errorf("%q: expected invalid schedule, got %v", schedule, err)
+		}
+	}
+}
+
+func TestScheduleAllTasksIsAllOrNothing(t *testing.T) {
+	a := &fakeAdapter{}
+	c := newFakeEngine(a,
+		Task{Name: "first", Schedule: "0 1 * * *", Command: "echo"},
+		Task{Name: "broken", Schedule: "0 1 * *", Command: "echo"},
+	)
+
+	if err := c.ScheduleAllTasks(); err == nil {
+		t.Fatal("invalid schedule should be rejected")
+	}
+	if len(a.jobs) != 0 {
+		t.Errorf("no task should be scheduled when one is invalid, got %v", a.jobs)
+	}
+}
+
+func TestEngineFromLoadedTasks(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFiles(t, dir, map[string]string{
+		"crontasks.yml": `- name: "backup"
+  command: "echo"
+`,
+	})
+	path := filepath.Join(dir, "crontasks.yml")
+	tasks, workflows, err := LoadTasks(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is not read again
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	c := NewCronTaskEngine(Config{TasksPath: path, Tasks: tasks, Workflows: workflows, NoAutoSchedule: true, Logger: discardLogger})
+	if got := c.GetTasks(); len(got) != 1 || got[0].Name != "backup" {
+		t.Errorf("engine tasks %+v", got)
+	}
+
+	c = NewCronTaskEngine(Config{Tasks: []Task{{Name: "broken", Schedule: "0 25 * * *", Command: "echo"}}, NoAutoSchedule: true, Logger: discardLogger})
+	if got := c.GetTasks(); len(got) != 0 {
+		t.Errorf("invalid tasks should be rejected, got %+v", got)
+	}
+}
+
+func TestEngineDoesNotChangeConfigTasks(t *testing.T) {
+	tasks := []Task{{Name: "backup", Command: "echo", SuccessPattern: "done"}}
+	c := NewCronTaskEngine(Config{Tasks: tasks, NoAutoSchedule: true, Logger: discardLogger})
+
+	if tasks[0].successRe != nil {
+		t.Error("the success_pattern was compiled into the caller's slice")
+	}
+	if got := c.GetTasks(); len(got) != 1 || got[0].successRe == nil {
+		t.Errorf("engine tasks %+v, want the compiled success_pattern", got)
+	}
+}
@@ -0,0 +1,75 @@
+//go:build linux
+
+package crontask
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// watchFiles reports changes to the target files using inotify, falling back to
+// polling every interval when inotify is unavailable. Directories are watched
+// instead of files because editors usually save by writing a new file and
+// renaming it over the old one.
+func watchFiles(targets watchTargets, interval time.Duration, quit <-chan struct{}) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return pollFiles(targets, interval, quit)
+	}
+
+	const mask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM | syscall.IN_CREATE | syscall.IN_DELETE
+	dirs := make(map[int32]string, len(targets))
+	for dir := range targets {
+		wd, err := syscall.InotifyAddWatch(fd, dir, mask)
+		if err != nil {
+			syscall.Close(fd)
+			return pollFiles(targets, interval, quit)
+		}
+		dirs[int32(wd)] = dir
+	}
+
+	// A non blocking descriptor wrapped in an os.File uses the runtime poller,
+	// so closing it unblocks the pending Read
+	events := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-quit
+		events.Close()
+	}()
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := events.Read(buf)
+			if err != nil {
+				return
+			}
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+				offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+				if !targets.matches(dirs[event.Wd], cString(nameBytes)) {
+					continue
+				}
+				select {
+				case changes <- struct{}{}:
+				default: // a change is already pending
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// cString returns the NUL padded inotify file name as a string
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !wasm
+
+package crontask
+
+import "time"
+
+// watchFiles reports changes to the target files by polling them every interval
+func watchFiles(targets watchTargets, interval time.Duration, quit <-chan struct{}) (<-chan struct{}, error) {
+	return pollFiles(targets, interval, quit)
+}
@@ -0,0 +1,117 @@
+//go:build !wasm
+
+package crontask
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// watchTargets are the directories to watch for a tasks path and the names
+// inside each directory that count as a change of the task set
+type watchTargets map[string][]string // directory => file names or glob patterns
+
+// newWatchTargets covers the files read on the last load plus the tasks path
+// itself, so a file missing or invalid at startup is picked up once fixed, and
+// for a directory or a glob tasks path any new matching file
+func newWatchTargets(tasksPath string, sources []string) watchTargets {
+	targets := make(watchTargets)
+	add := func(dir, pattern string) {
+		if !slices.Contains(targets[dir], pattern) {
+			targets[dir] = append(targets[dir], pattern)
+		}
+	}
+
+	for _, source := range sources {
+		add(filepath.Dir(source), filepath.Base(source))
+	}
+
+	if info, err := os.Stat(tasksPath); err == nil && info.IsDir() {
+		if filepath.Base(tasksPath) == "cron.d" {
+			add(tasksPath, "*")
+		} else {
+			for _, ext := range taskFileExtensions {
+				add(tasksPath, "*"+ext)
+			}
+		}
+	} else {
+		add(filepath.Dir(tasksPath), filepath.Base(tasksPath))
+	}
+	return targets
+}
+
+// matches reports whether a file name inside dir belongs to the task set
+func (w watchTargets) matches(dir, name string) bool {
+	for _, pattern := range w[dir] {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// signature summarizes the modification time and size of every target file
+func (w watchTargets) signature() string {
+	dirs := make([]string, 0, len(w))
+	for dir := range w {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var sig strings.Builder
+	for _, dir := range dirs {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if !w.matches(dir, entry.Name()) {
+				continue
+			}
+			if info, err := entry.Info(); err == nil {
+				sig.WriteString(filepath.Join(dir, entry.Name()) + " " + info.ModTime().String() + " " + strconv.FormatInt(info.Size(), 10) + "\n")
+			}
+		}
+	}
+	return sig.String()
+}
+
+// pollFiles reports a change every time the target files are modified, added or removed
+func pollFiles(targets watchTargets, interval time.Duration, quit <-chan struct{}) (<-chan struct{}, error) {
+	if len(targets) == 0 {
+		return nil, newErr("no tasks files to watch")
+	}
+	last := targets.signature()
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+			}
+
+			sig := targets.signature()
+			if sig == last {
+				continue
+			}
+			last = sig
+
+			select {
+			case changes <- struct{}{}:
+			default: // a change is already pending
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// watchTasksFiles reports changes to the task set loaded from tasksPath
+func watchTasksFiles(tasksPath string, sources []string, interval time.Duration, quit <-chan struct{}) (<-chan struct{}, error) {
+	return watchFiles(newWatchTargets(tasksPath, sources), interval, quit)
+}
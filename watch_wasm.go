@@ -0,0 +1,15 @@
+//go:build wasm
+
+package crontask
+
+import "time"
+
+// watchTasksFiles is not available in the browser, the tasks file is fetched once
+func watchTasksFiles(tasksPath string, sources []string, interval time.Duration, quit <-chan struct{}) (<-chan struct{}, error) {
+	return nil, newErr("watching the tasks file is not supported in WASM")
+}
+
+// notifyReload is not available in the browser, there are no signals
+func notifyReload(quit <-chan struct{}) (<-chan struct{}, error) {
+	return nil, newErr("reload signals are not supported in WASM")
+}
@@ -0,0 +1,120 @@
+package crontask
+
+import "sync"
+
+// Workflow runs a set of tasks as a unit, each step starting as soon as
+// all the steps it depends on have succeeded. Independent steps run in parallel.
+//
+//	workflows:
+//	  - name: "etl"
+//	    schedule: "0 2 * * *"
+//	    steps:
+//	      - task: "extract_a"
+//	      - task: "extract_b"
+//	      - task: "load"
+//	        depends_on: ["extract_a", "extract_b"]
+type Workflow struct {
+	Name     string         `yaml:"name"`     // eg: "Nightly ETL"
+	Schedule string         `yaml:"schedule"` // eg: "0 2 * * *", empty to run only on demand
+	Steps    []WorkflowStep `yaml:"steps"`
+}
+
+// WorkflowStep is a node of a workflow referencing a loaded task by name
+type WorkflowStep struct {
+	Task      string   `yaml:"task"`       // eg: "load"
+	DependsOn []string `yaml:"depends_on"` // eg: ["extract_a", "extract_b"]
+}
+
+// RunWorkflow executes a workflow by its name and waits for all its steps.
+// A step whose dependencies didn't all succeed is recorded as skipped.
+func (c *CronTaskEngine) RunWorkflow(name string) error {
+	wf, ok := c.findWorkflow(name)
+	if !ok {
+		return newErr("workflow not found: " + name)
+	}
+	c.logger.Info("workflow started", "workflow", name)
+
+	done := make(map[string]chan struct{}, len(wf.Steps))
+	for _, step := range wf.Steps {
+		done[step.Task] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	statuses := make(map[string]RunStatus, len(wf.Steps))
+
+	var wg sync.WaitGroup
+	for _, step := range wf.Steps {
+		wg.Add(1)
+		go func(step WorkflowStep) {
+			defer wg.Done()
+			defer close(done[step.Task])
+
+			for _, dep := range step.DependsOn {
+				<-done[dep]
+			}
+
+			mu.Lock()
+			var blocked string
+			for _, dep := range step.DependsOn {
+				if statuses[dep] != StatusSuccess {
+					blocked = dep
+					break
+				}
+			}
+			mu.Unlock()
+
+			var status RunStatus
+			if blocked != "" {
+				result := RunResult{Task: step.Task, Workflow: wf.Name, Status: StatusSkipped, ExitCode: -1, Reason: "dependency " + blocked + " did not succeed"}
+				c.logger.Info("task skipped", "task", step.Task, "workflow", wf.Name, "reason", result.Reason)
+				task, _ := c.findTask(step.Task)
+				task.workflow = wf.Name
+				c.finished(task, result, nil)
+				status = result.Status
+			} else {
+				task, _ := c.findTask(step.Task)
+				task.workflow = wf.Name
+				result, _ := c.runTask(task)
+				status = result.Status
+			}
+
+			mu.Lock()
+			statuses[step.Task] = status
+			mu.Unlock()
+		}(step)
+	}
+	wg.Wait()
+
+	var failed []string
+	for _, step := range wf.Steps {
+		if statuses[step.Task] == StatusFailed {
+			failed = append(failed, step.Task)
+		}
+	}
+	if len(failed) > 0 {
+		return newErr("workflow", wf.Name, "failed steps:", failed)
+	}
+	c.logger.Info("workflow succeeded", "workflow", wf.Name)
+	return nil
+}
+
+// findWorkflow returns the loaded workflow with the given name
+func (c *CronTaskEngine) findWorkflow(name string) (Workflow, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, wf := range c.workflows {
+		if wf.Name == name {
+			return wf, true
+		}
+	}
+	return Workflow{}, false
+}
+
+// GetWorkflows returns a copy of all loaded workflows
+func (c *CronTaskEngine) GetWorkflows() []Workflow {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	workflowsCopy := make([]Workflow, len(c.workflows))
+	copy(workflowsCopy, c.workflows)
+	return workflowsCopy
+}
@@ -0,0 +1,112 @@
+package crontask
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func etlTasks() []Task {
+	return []Task{{Name: "extract_a", Command: "echo"}, {Name: "extract_b", Command: "echo"}, {Name: "load", Command: "echo"}, {Name: "report", Command: "echo"}}
+}
+
+func TestCheckWorkflow(t *testing.T) {
+	check := func(wf Workflow) error {
+		return validateTaskFile(taskFile{Tasks: etlTasks(), Workflows: []Workflow{wf}}, nil).err()
+	}
+
+	valid := Workflow{Name: "etl", Steps: []WorkflowStep{
+		{Task: "extract_a"},
+		{Task: "extract_b"},
+		{Task: "load", DependsOn: []string{"extract_a", "extract_b"}},
+	}}
+	if err := check(valid); err != nil {
+		t.Errorf("valid workflow rejected: %v", err)
+	}
+
+	invalid := map[string]Workflow{
+		"unknown task": {Name: "etl", Steps: []WorkflowStep{{Task: "missing"}}},
+		"not a step":   {Name: "etl", Steps: []WorkflowStep{{Task: "load", DependsOn: []string{"extract_a"}}}},
+		"duplicated":   {Name: "etl", Steps: []WorkflowStep{{Task: "load"}, {Task: "load"}}},
+		"no steps":     {Name: "etl"},
+		"cycle": {Name: "etl", Steps: []WorkflowStep{
+			{Task: "extract_a", DependsOn: []string{"load"}},
+			{Task: "load", DependsOn: []string{"report"}},
+			{Task: "report", DependsOn: []string{"extract_a"}},
+		}},
+	}
+	for name, wf := range invalid {
+		if err := check(wf); err == nil {
+			t.Errorf("%s: workflow should be rejected", name)
+		}
+	}
+}
+
+func TestRunWorkflow(t *testing.T) {
+	a := &fakeAdapter{exitCodes: map[string]int{"extract_b": 1}}
+	c := newFakeEngine(a, etlTasks()...)
+	c.workflows = []Workflow{{Name: "etl", Steps: []WorkflowStep{
+		{Task: "extract_a"},
+		{Task: "extract_b"},
+		{Task: "load", DependsOn: []string{"extract_a", "extract_b"}},
+		{Task: "report", DependsOn: []string{"extract_a"}},
+	}}}
+
+	err := c.RunWorkflow("etl")
+	if err == nil || !strings.Contains(err.Error(), "extract_b") {
+		t.Errorf("expected extract_b failure, got %v", err)
+	}
+
+	executed := a.names()
+	slices.Sort(executed)
+	if want := []string{"extract_a", "extract_b", "report"}; !slices.Equal(executed, want) {
+		t.Errorf("executed %v, want %v", executed, want)
+	}
+
+	statuses := map[string]RunStatus{}
+	for _, r := range c.History() {
+		if r.Workflow != "etl" {
+			t.Errorf("run of %s not recorded as part of the workflow", r.Task)
+		}
+		statuses[r.Task] = r.Status
+	}
+	want := map[string]RunStatus{"extract_a": StatusSuccess, "extract_b": StatusFailed, "load": StatusSkipped, "report": StatusSuccess}
+	for task, status := range want {
+		if statuses[task] != status {
+			t.Errorf("%s recorded as %q, want %q", task, statuses[task], status)
+		}
+	}
+}
+
+func TestLoadWorkflowsFromYaml(t *testing.T) {
+	path := filepath.Join(t.TempDir(), filePathDefault)
+	content := `tasks:
+  - name: "extract_a"
+    command: "echo"
+  - name: "load"
+    command: "echo"
+workflows:
+  - name: "etl"
+    schedule: "0 2 * * *"
+    steps:
+      - task: "extract_a"
+      - task: "load"
+        depends_on: ["extract_a"]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := newCronAdapter(discardLogger).GetTasksFromPath(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Tasks) != 2 || len(file.Workflows) != 1 {
+		t.Fatalf("loaded %d tasks and %d workflows, want 2 and 1", len(file.Tasks), len(file.Workflows))
+	}
+	if deps := file.Workflows[0].Steps[1].DependsOn; !slices.Equal(deps, []string{"extract_a"}) {
+		t.Errorf("depends_on = %v", deps)
+	}
+}
@@ -2,62 +2,787 @@ package crontask
 
 import (
 	"bytes"
-	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // ymlParser handles parsing of YAML content for both frontend and backend
 type ymlParser struct{}
 
-// ParseYAML parses YAML content bytes into Tasks
+// ParseYAML parses YAML content bytes into validated Tasks
 func (p ymlParser) ParseYAML(data []byte) (Tasks, error) {
 	if len(bytes.TrimSpace(data)) == 0 {
 		return nil, newErr("empty YAML content")
 	}
 
-	// Parse YAML content with regex for best performance
-	return p.parseWithRegex(data)
+	file, err := parseTaskData(data, "", FormatYAML)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTaskFile(file, nil).err(); err != nil {
+		return nil, err
+	}
+	return file.Tasks, nil
+}
+
+// parseYAMLNodes parses the first document of a YAML file into nodes. It reads
+// the subset of YAML that configuration files use: block mappings and
+// sequences, flow [lists] and {mappings}, plain, quoted and block (| and >)
+// scalars, comments, anchors, aliases and << merge keys. Tags are ignored.
+func parseYAMLNodes(data []byte) (*node, error) {
+	p := &yamlParser{data: data, anchors: make(map[string]*node)}
+	if err := p.lineContent(); err != nil {
+		return nil, err
+	}
+	if p.eof && p.peek("---") {
+		// Explicit start of the document
+		p.pos += 3
+		if err := p.nextContent(); err != nil {
+			return nil, err
+		}
+	}
+	if p.eof {
+		return nil, nil
+	}
+
+	root, err := p.block(p.indent, -1)
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof {
+		return nil, p.errorf("unexpected content, check the indentation")
+	}
+	return root, nil
+}
+
+// yamlParser reads block structures line by line. After every node it is
+// left at the first content of the next line, at column indent, or at eof.
+type yamlParser struct {
+	data    []byte
+	pos     int
+	indent  int  // column of the current line content, 0 based
+	eof     bool // end of the document
+	anchors map[string]*node
+}
+
+func (p *yamlParser) errorf(message string) error {
+	return &syntaxError{offset: p.pos, message: message}
+}
+
+func (p *yamlParser) newNode(kind nodeKind) *node {
+	line, column := lineColumn(p.data, p.pos)
+	return &node{kind: kind, line: line, column: column}
+}
+
+// at returns the byte at offset i from the current position, 0 past the end
+func (p *yamlParser) at(i int) byte {
+	if p.pos+i >= len(p.data) {
+		return 0
+	}
+	return p.data[p.pos+i]
+}
+
+func (p *yamlParser) peek(s string) bool {
+	return bytes.HasPrefix(p.data[p.pos:], []byte(s))
+}
+
+func (p *yamlParser) column() int {
+	return p.pos - (bytes.LastIndexByte(p.data[:p.pos], '\n') + 1)
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == 0
+}
+
+func (p *yamlParser) skipSpaces() {
+	for p.at(0) == ' ' || p.at(0) == '\t' {
+		p.pos++
+	}
+}
+
+// atLineEnd reports whether only a comment, if anything, is left on the line
+func (p *yamlParser) atLineEnd() bool {
+	c := p.at(0)
+	return c == 0 || c == '\n' || c == '\r' || c == '#'
+}
+
+// nextContent checks nothing but a comment is left on the current line and
+// moves to the first content of the next line that has any
+func (p *yamlParser) nextContent() error {
+	p.skipSpaces()
+	if !p.atLineEnd() {
+		return p.errorf("unexpected " + strconv.QuoteRune(rune(p.at(0))) + ", check the indentation or quote the value")
+	}
+	for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+		p.pos++
+	}
+	if p.pos < len(p.data) {
+		p.pos++
+	}
+	return p.lineContent()
+}
+
+// lineContent moves from the start of a line to the first content of the
+// document, skipping blank and comment lines. A document marker ends it.
+func (p *yamlParser) lineContent() error {
+	for p.pos < len(p.data) {
+		lineStart := p.pos
+		for p.at(0) == ' ' {
+			p.pos++
+		}
+		if tab := p.pos; p.at(0) == '\t' {
+			// Tabs are only fine on lines without content
+			p.skipSpaces()
+			if !p.atLineEnd() {
+				return &syntaxError{offset: tab, message: "tabs are not allowed for indentation"}
+			}
+		}
+		if p.atLineEnd() {
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+			p.pos++
+			continue
+		}
+		if p.pos == lineStart && (p.peek("---") || p.peek("...")) && isBlank(p.at(3)) {
+			break
+		}
+		p.indent, p.eof = p.pos-lineStart, false
+		return nil
+	}
+	p.pos = min(p.pos, len(p.data))
+	p.eof = true
+	return nil
+}
+
+func (p *yamlParser) isSequenceEntry() bool {
+	return p.at(0) == '-' && isBlank(p.at(1))
+}
+
+// isMappingKey reports whether the current line content is a "key: value" pair
+func (p *yamlParser) isMappingKey() bool {
+	i := p.pos
+	switch p.at(0) {
+	case '"', '\'':
+		quote := p.at(0)
+		for i++; i < len(p.data) && p.data[i] != quote && p.data[i] != '\n'; i++ {
+			if quote == '"' && p.data[i] == '\\' {
+				i++
+			} else if quote == '\'' && p.data[i] == '\'' && i+1 < len(p.data) && p.data[i+1] == '\'' {
+				i++
+			}
+		}
+		if i >= len(p.data) || p.data[i] != quote {
+			return false
+		}
+		for i++; i < len(p.data) && (p.data[i] == ' ' || p.data[i] == '\t'); i++ {
+		}
+		return i < len(p.data) && p.data[i] == ':' && (i+1 == len(p.data) || isBlank(p.data[i+1]))
+	case '[', '{', '|', '>', '*', '&', '!', '%', '@', '`', '#':
+		return false
+	}
+
+	for ; i < len(p.data) && p.data[i] != '\n'; i++ {
+		switch {
+		case p.data[i] == ':' && (i+1 == len(p.data) || isBlank(p.data[i+1])):
+			return true
+		case p.data[i] == '#' && (p.data[i-1] == ' ' || p.data[i-1] == '\t'):
+			return false
+		}
+	}
+	return false
+}
+
+// block parses the node at the current line content, at column indent,
+// inside a parent node at column parent
+func (p *yamlParser) block(indent, parent int) (*node, error) {
+	switch {
+	case p.isSequenceEntry():
+		return p.sequence(indent)
+	case p.isMappingKey():
+		return p.mapping(indent)
+	}
+	return p.scalar(parent)
+}
+
+func (p *yamlParser) sequence(indent int) (*node, error) {
+	seq := p.newNode(sequenceNode)
+	for {
+		p.pos++ // -
+		item, err := p.value(indent, false)
+		if err != nil {
+			return nil, err
+		}
+		seq.items = append(seq.items, item)
+
+		switch {
+		case p.eof || p.indent < indent:
+			return seq, nil
+		case p.indent > indent:
+			return nil, p.errorf("bad indentation of a list item")
+		case !p.isSequenceEntry():
+			// A mapping key of the parent at the same column eg: "tasks:\n- ...\nworkflows:"
+			return seq, nil
+		}
+	}
+}
+
+func (p *yamlParser) mapping(indent int) (*node, error) {
+	m := p.newNode(mappingNode)
+	var merges []*node
+	for {
+		keyOffset := p.pos
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		p.pos++ // :
+		value, err := p.value(indent, true)
+		if err != nil {
+			return nil, err
+		}
+		if key.value == "<<" {
+			// Merge keys are applied once all the own keys are known
+			if !isMergeable(value) {
+				return nil, &syntaxError{offset: keyOffset, message: "<< merge key needs a mapping or a list of mappings"}
+			}
+			merges = append(merges, value)
+		} else {
+			m.pairs = append(m.pairs, nodePair{key: key, value: value})
+		}
+
+		if p.eof || p.indent < indent {
+			break
+		}
+		if p.indent > indent {
+			return nil, p.errorf("bad indentation of a mapping entry")
+		}
+		if !p.isMappingKey() {
+			return nil, p.errorf("expected a \"key: value\" pair")
+		}
+	}
+
+	// Add the pairs of the merged mappings that m doesn't define itself
+	for _, merge := range merges {
+		sources := []*node{merge}
+		if merge.kind == sequenceNode {
+			sources = merge.items
+		}
+		for _, source := range sources {
+			for _, pair := range source.pairs {
+				if lookupPair(m, pair.key.value) == nil {
+					m.pairs = append(m.pairs, pair)
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// isMergeable reports whether a << merge key value is a mapping or a list of mappings
+func isMergeable(n *node) bool {
+	if n.kind == sequenceNode {
+		for _, item := range n.items {
+			if item.kind != mappingNode {
+				return false
+			}
+		}
+		return true
+	}
+	return n.kind == mappingNode
+}
+
+func (p *yamlParser) key() (*node, error) {
+	k := p.newNode(scalarNode)
+	if c := p.at(0); c == '"' || c == '\'' {
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		k.value = s
+	} else {
+		start := p.pos
+		for !(p.at(0) == ':' && isBlank(p.at(1))) {
+			p.pos++
+		}
+		k.value = strings.TrimRight(string(p.data[start:p.pos]), " \t")
+	}
+	p.skipSpaces()
+	if p.at(0) != ':' {
+		return nil, p.errorf("expected ':' after the key")
+	}
+	return k, nil
+}
+
+// value parses what follows a "key:" or a "- ", within a parent node at
+// column parent. A mapping value may be a list at the same column.
+func (p *yamlParser) value(parent int, isMapValue bool) (*node, error) {
+	p.skipSpaces()
+	anchor, err := p.properties()
+	if err != nil {
+		return nil, err
+	}
+
+	var n *node
+	switch {
+	case p.atLineEnd():
+		// The value is on the next lines, or it is empty
+		n = p.newNode(scalarNode)
+		n.isNull = true
+		if err := p.nextContent(); err != nil {
+			return nil, err
+		}
+		if !p.eof && (p.indent > parent || isMapValue && p.indent == parent && p.isSequenceEntry()) {
+			n, err = p.block(p.indent, parent)
+		}
+
+	case p.isAlias():
+		n = p.alias()
+		err = p.nextContent()
+
+	case !isMapValue && (p.isSequenceEntry() || p.isMappingKey()):
+		// Compact nested block eg: "- name: backup" or "- - a"
+		n, err = p.block(p.column(), parent)
+
+	default:
+		n, err = p.scalar(parent)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if anchor != "" {
+		p.anchors[anchor] = n
+	}
+	return n, nil
+}
+
+// properties skips the tags and returns the anchor written before a value
+func (p *yamlParser) properties() (anchor string, err error) {
+	for {
+		switch p.at(0) {
+		case '&':
+			p.pos++
+			anchor = p.name()
+			if anchor == "" {
+				return "", p.errorf("expected an anchor name")
+			}
+		case '!':
+			p.name()
+		default:
+			return anchor, nil
+		}
+		p.skipSpaces()
+	}
+}
+
+// name reads an anchor, alias or tag name
+func (p *yamlParser) name() string {
+	start := p.pos
+	for !isBlank(p.at(0)) && !strings.ContainsRune(",[]{}", rune(p.at(0))) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+// isAlias reports whether the value is *name of a known anchor. Anything
+// else starting with * is plain text eg: schedule: */5 * * * *
+func (p *yamlParser) isAlias() bool {
+	if p.at(0) != '*' {
+		return false
+	}
+	start := p.pos
+	p.pos++
+	_, ok := p.anchors[p.name()]
+	p.pos = start
+	return ok
+}
+
+func (p *yamlParser) alias() *node {
+	p.pos++ // *
+	return p.anchors[p.name()]
+}
+
+// scalar parses an inline value: a flow collection, a quoted, block or
+// plain scalar, and moves to the next line
+func (p *yamlParser) scalar(parent int) (*node, error) {
+	switch p.at(0) {
+	case '[', '{':
+		n, err := p.flow()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.nextContent()
+	case '"', '\'':
+		n := p.newNode(scalarNode)
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		n.value = s
+		return n, p.nextContent()
+	case '|', '>':
+		return p.blockScalar(parent)
+	}
+	return p.plain(parent)
+}
+
+// plain reads an unquoted scalar, folding the more indented lines that follow
+func (p *yamlParser) plain(parent int) (*node, error) {
+	n := p.newNode(scalarNode)
+	text := p.plainLine()
+	for {
+		if err := p.nextContent(); err != nil {
+			return nil, err
+		}
+		if p.eof || p.indent <= parent {
+			break
+		}
+		if p.isMappingKey() {
+			return nil, p.errorf("bad indentation of a mapping entry")
+		}
+		text += " " + p.plainLine()
+	}
+
+	n.value = text
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		n.isNull = true
+	}
+	return n, nil
+}
+
+// plainLine reads the rest of the line up to a comment
+func (p *yamlParser) plainLine() string {
+	start := p.pos
+	for c := p.at(0); c != 0 && c != '\n' && c != '\r'; c = p.at(0) {
+		if c == '#' && p.pos > start && (p.data[p.pos-1] == ' ' || p.data[p.pos-1] == '\t') {
+			break
+		}
+		p.pos++
+	}
+	return strings.TrimRight(string(p.data[start:p.pos]), " \t")
+}
+
+// quoted reads a 'single' or "double" quoted scalar, which may span lines
+func (p *yamlParser) quoted() (string, error) {
+	start := p.pos
+	quote := p.at(0)
+	p.pos++
+
+	var out []byte
+	for {
+		c := p.at(0)
+		switch {
+		case c == 0:
+			p.pos = start
+			return "", p.errorf("unterminated quoted string")
+		case c == quote && quote == '\'' && p.at(1) == '\'':
+			out = append(out, '\'')
+			p.pos += 2
+		case c == quote:
+			p.pos++
+			return string(out), nil
+		case c == '\\' && quote == '"':
+			var err error
+			if out, err = p.escape(out); err != nil {
+				return "", err
+			}
+		case c == '\n' || c == '\r' && p.at(1) == '\n':
+			out = p.foldLines(out)
+		default:
+			out = append(out, c)
+			p.pos++
+		}
+	}
+}
+
+// foldLines joins the lines of a quoted scalar: one line break becomes a
+// space and every empty line a new line
+func (p *yamlParser) foldLines(out []byte) []byte {
+	out = bytes.TrimRight(out, " \t")
+	breaks := 0
+	for {
+		if p.at(0) == '\r' {
+			p.pos++
+		}
+		if p.at(0) != '\n' {
+			break
+		}
+		p.pos++
+		breaks++
+		p.skipSpaces()
+	}
+	if breaks == 1 {
+		return append(out, ' ')
+	}
+	return append(out, bytes.Repeat([]byte{'\n'}, breaks-1)...)
+}
+
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f",
+	'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\",
+	'N': "\u0085", '_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+}
+
+func (p *yamlParser) escape(out []byte) ([]byte, error) {
+	c := p.at(1)
+	if s, ok := yamlEscapes[c]; ok {
+		p.pos += 2
+		return append(out, s...), nil
+	}
+
+	size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+	switch {
+	case c == '\n' || c == '\r':
+		// An escaped line break joins the lines without a space
+		p.pos++
+		if p.at(0) == '\r' {
+			p.pos++
+		}
+		p.pos++
+		p.skipSpaces()
+		return out, nil
+	case size > 0 && p.pos+2+size <= len(p.data):
+		code, err := strconv.ParseUint(string(p.data[p.pos+2:p.pos+2+size]), 16, 32)
+		if err == nil && utf8.ValidRune(rune(code)) {
+			p.pos += 2 + size
+			return utf8.AppendRune(out, rune(code)), nil
+		}
+	}
+	return nil, p.errorf("invalid escape \\" + string(c))
 }
 
-// parseWithRegex uses regular expressions to extract tasks from YAML formats
-func (p ymlParser) parseWithRegex(data []byte) (Tasks, error) {
-	var tasks []Task
+// blockScalar reads a | literal or > folded scalar with its optional
+// chomping (- or +) and indentation indicators
+func (p *yamlParser) blockScalar(parent int) (*node, error) {
+	n := p.newNode(scalarNode)
+	folded := p.at(0) == '>'
+	p.pos++
 
-	// Pattern for a task with quoted or unquoted values
-	pattern := regexp.MustCompile(`(?m)- *name: *["']?([^"'\n]+)["']?\n *schedule: *["']?([^"'\n]+)["']?\n *command: *["']?([^"'\n]+)["']?\n *(?:args: *["']?([^"'\n]*)["']?)?`)
+	chomp, explicit := byte(0), 0
+	for i := 0; i < 2; i++ {
+		switch c := p.at(0); {
+		case c == '-' || c == '+':
+			chomp = c
+		case c >= '1' && c <= '9':
+			explicit = int(c - '0')
+		default:
+			continue
+		}
+		p.pos++
+	}
+	p.skipSpaces()
+	if !p.atLineEnd() {
+		return nil, p.errorf("unexpected content after the block scalar indicator")
+	}
+	for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+		p.pos++
+	}
+	p.pos++
 
-	// Direct list pattern
-	matches := pattern.FindAllSubmatch(data, -1)
+	indent := 0
+	if explicit > 0 {
+		indent = max(parent, 0) + explicit
+	}
+	var lines []string
+	for p.pos < len(p.data) {
+		lineStart := p.pos
+		end := bytes.IndexByte(p.data[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.data) - p.pos
+		}
+		line := strings.TrimSuffix(string(p.data[p.pos:p.pos+end]), "\r")
+		content := strings.TrimLeft(line, " ")
+		lineIndent := len(line) - len(content)
 
-	// If no direct matches, try looking inside a tasks: block
-	if len(matches) == 0 {
-		// Check if there's a tasks: section and try inside it
-		tasksSection := regexp.MustCompile(`(?s)tasks:(.*$)`).FindSubmatch(data)
-		if len(tasksSection) > 1 {
-			matches = pattern.FindAllSubmatch(tasksSection[1], -1)
+		if strings.TrimSpace(line) != "" {
+			if indent == 0 {
+				if lineIndent <= parent {
+					break
+				}
+				indent = lineIndent
+			}
+			if lineIndent < indent {
+				break
+			}
+		}
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, "")
+		} else {
+			lines = append(lines, line[indent:])
 		}
+		p.pos = lineStart + end + 1
 	}
+	p.pos = min(p.pos, len(p.data))
 
-	// Process all matches
-	for _, match := range matches {
-		if len(match) >= 4 {
-			task := Task{
-				Name:     string(match[1]),
-				Schedule: string(match[2]),
-				Command:  string(match[3]),
+	// Trailing empty lines only matter for the + chomping
+	trailing := 0
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	if folded {
+		n.value = foldBlockLines(lines)
+	} else {
+		n.value = strings.Join(lines, "\n")
+	}
+	switch {
+	case chomp == '+':
+		n.value += strings.Repeat("\n", trailing+1)
+	case chomp != '-' && len(lines) > 0:
+		n.value += "\n"
+	}
+	return n, p.lineContent()
+}
+
+// foldBlockLines joins the lines of a > scalar, keeping the line breaks
+// around empty and more indented lines
+func foldBlockLines(lines []string) string {
+	moreIndented := func(line string) bool {
+		return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+	}
+
+	var out strings.Builder
+	lastText := "" // last non empty line
+	for i, line := range lines {
+		switch {
+		case i == 0:
+		case line == "":
+			out.WriteByte('\n')
+		case lines[i-1] == "":
+			if lastText == "" || moreIndented(line) || moreIndented(lastText) {
+				out.WriteByte('\n')
 			}
+		case moreIndented(line) || moreIndented(lines[i-1]):
+			out.WriteByte('\n')
+		default:
+			out.WriteByte(' ')
+		}
+		out.WriteString(line)
+		if line != "" {
+			lastText = line
+		}
+	}
+	return out.String()
+}
 
-			// Args are optional
-			if len(match) >= 5 && len(match[4]) > 0 {
-				task.Args = string(match[4])
+// flow reads a [list] or {mapping} that may span lines
+func (p *yamlParser) flow() (*node, error) {
+	closing := byte(']')
+	n := p.newNode(sequenceNode)
+	if p.at(0) == '{' {
+		closing = '}'
+		n.kind = mappingNode
+	}
+	p.pos++
+
+	for {
+		if err := p.skipFlowSpace(); err != nil {
+			return nil, err
+		}
+		if p.at(0) == closing {
+			p.pos++
+			return n, nil
+		}
+
+		if n.kind == sequenceNode {
+			item, err := p.flowValue()
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+		} else {
+			key, err := p.flowValue()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.skipFlowSpace(); err != nil {
+				return nil, err
+			}
+			if p.at(0) != ':' {
+				return nil, p.errorf("expected ':' after the key")
 			}
+			p.pos++
+			if err := p.skipFlowSpace(); err != nil {
+				return nil, err
+			}
+
+			value := p.newNode(scalarNode)
+			value.isNull = true
+			if p.at(0) != ',' && p.at(0) != '}' {
+				if value, err = p.flowValue(); err != nil {
+					return nil, err
+				}
+			}
+			n.pairs = append(n.pairs, nodePair{key: key, value: value})
+		}
 
-			tasks = append(tasks, task)
+		if err := p.skipFlowSpace(); err != nil {
+			return nil, err
+		}
+		switch p.at(0) {
+		case ',':
+			p.pos++
+		case closing:
+		default:
+			return nil, p.errorf("expected ',' or '" + string(closing) + "'")
 		}
 	}
-	// Check if we found any tasks
-	if len(tasks) == 0 {
-		return nil, newErr("no valid tasks found in YAML")
+}
+
+// skipFlowSpace skips spaces, line breaks and comments inside a flow collection
+func (p *yamlParser) skipFlowSpace() error {
+	for {
+		switch c := p.at(0); {
+		case c == 0:
+			return p.errorf("unterminated flow collection")
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			p.pos++
+		case c == '#':
+			for p.at(0) != '\n' && p.at(0) != 0 {
+				p.pos++
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *yamlParser) flowValue() (*node, error) {
+	switch p.at(0) {
+	case '[', '{':
+		return p.flow()
+	case '"', '\'':
+		n := p.newNode(scalarNode)
+		s, err := p.quoted()
+		n.value = s
+		return n, err
+	}
+	if p.isAlias() {
+		return p.alias(), nil
 	}
 
-	return tasks, nil
+	// Plain scalar up to an indicator of the collection
+	n := p.newNode(scalarNode)
+	start := p.pos
+	for c := p.at(0); c != 0 && !strings.ContainsRune(",[]{}\r\n", rune(c)); c = p.at(0) {
+		if c == ':' && (isBlank(p.at(1)) || strings.ContainsRune(",]}", rune(p.at(1)))) {
+			break
+		}
+		if c == '#' && p.pos > start && p.data[p.pos-1] == ' ' {
+			break
+		}
+		p.pos++
+	}
+	n.value = strings.TrimRight(string(p.data[start:p.pos]), " \t")
+	if n.value == "" {
+		return nil, p.errorf("expected a value")
+	}
+	if n.value == "~" || n.value == "null" {
+		n.isNull = true
+	}
+	return n, nil
 }
@@ -0,0 +1,83 @@
+package crontask
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConvertCrontab converts a classic crontab into the content of a crontasks.yml
+// file. withUser is true for /etc/crontab and /etc/cron.d files.
+func ConvertCrontab(data []byte, withUser bool) ([]byte, error) {
+	tasks, err := ParseCrontab(data, withUser)
+	if err != nil {
+		return nil, err
+	}
+	return marshalTasksYAML(tasks), nil
+}
+
+// marshalTasksYAML writes tasks as a YAML list in the style of the README,
+// leaving out the fields that are not set
+func marshalTasksYAML(tasks []Task) []byte {
+	var b strings.Builder
+	for i, task := range tasks {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		writeYAMLFields(&b, reflect.ValueOf(task), "- ", "  ")
+	}
+	return []byte(b.String())
+}
+
+// writeYAMLFields writes the non zero yaml tagged fields of a struct, the first
+// line prefixed by first and the rest by indent
+func writeYAMLFields(b *strings.Builder, v reflect.Value, first, indent string) {
+	prefix := first
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		value := v.Field(i)
+		if !field.IsExported() || name == "" || name == "-" || value.IsZero() {
+			continue
+		}
+
+		b.WriteString(prefix + name + ":")
+		prefix = indent
+
+		switch value.Kind() {
+		case reflect.Map:
+			keys := make([]string, 0, value.Len())
+			for _, k := range value.MapKeys() {
+				keys = append(keys, k.String())
+			}
+			sort.Strings(keys)
+			b.WriteString("\n")
+			for _, k := range keys {
+				b.WriteString(indent + "  " + k + ": " + yamlScalar(value.MapIndex(reflect.ValueOf(k))) + "\n")
+			}
+		case reflect.Slice:
+			items := make([]string, value.Len())
+			for j := range items {
+				items[j] = yamlScalar(value.Index(j))
+			}
+			b.WriteString(" [" + strings.Join(items, ", ") + "]\n")
+		default:
+			b.WriteString(" " + yamlScalar(value) + "\n")
+		}
+	}
+}
+
+// yamlScalar writes strings double quoted and numbers and booleans as is.
+// A $ is doubled so the shell, not the loader, expands the variables.
+func yamlScalar(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(strings.ReplaceAll(v.String(), "$", "$$"))
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	}
+	return strconv.Quote(v.String())
+}
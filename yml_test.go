@@ -1,6 +1,8 @@
 package crontask
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -114,3 +116,80 @@ tasks:
 		})
 	}
 }
+
+func TestParseYAMLSubset(t *testing.T) {
+	data := `---
+# Keys in any order, comments everywhere
+tasks:
+- name: base
+  command: /bin/true
+  <<: &defaults
+    user: "backup"   # inline comment
+    env: {PGHOST: db1, PGPORT: "5432"}
+- command: /opt/backup.sh
+  name: backup
+  schedule: '*/30 * * * *'
+  args: "--label \"nightly\" --path 'C:\\dumps'"
+  <<: *defaults
+  success_codes: [0, 3]
+  fail_on_stderr: yes
+  stdin: |
+    line one
+      indented
+  on_failure:
+    - notify
+- name: notify
+  command: mail
+  args: >-
+    -s "backup failed"
+    ops@example.com
+  schedule: */5 * * * *
+  user: ~
+  stdin: 'it''s
+    folded'
+`
+	file, err := parseTaskData([]byte(data), "tasks.yml", FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Task{
+		{Name: "base", Command: "/bin/true", User: "backup", Env: map[string]string{"PGHOST": "db1", "PGPORT": "5432"}},
+		{Name: "backup", Schedule: "*/30 * * * *", Command: "/opt/backup.sh", Args: `--label "nightly" --path 'C:\dumps'`,
+			User: "backup", Env: map[string]string{"PGHOST": "db1", "PGPORT": "5432"}, SuccessCodes: []int{0, 3},
+			FailOnStderr: true, Stdin: "line one\n  indented\n", OnFailure: []string{"notify"}},
+		{Name: "notify", Schedule: "*/5 * * * *", Command: "mail", Args: `-s "backup failed" ops@example.com`, Stdin: "it's folded"},
+	}
+	if !reflect.DeepEqual(file.Tasks, want) {
+		t.Errorf("tasks\n got %+v\nwant %+v", file.Tasks, want)
+	}
+	if p := file.positions["tasks[2].schedule"]; p.line != 26 || p.column != 13 {
+		t.Errorf("tasks[2].schedule position %d:%d, want 26:13", p.line, p.column)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for _, tc := range []struct{ data, want string }{
+		{"- name: a\n   command: b\n", "tasks.yml:2:4: bad indentation of a mapping entry"},
+		{"- name: a\n\tcommand: b\n", "tasks.yml:2:1: tabs are not allowed for indentation"},
+		{"- name: \"a\n", "tasks.yml:1:9: unterminated quoted string"},
+		{"- name: a\n  args: [1, 2\n", "tasks.yml:3:1: unterminated flow collection"},
+		{"- name: \"a\" b\n", `tasks.yml:1:13: unexpected 'b', check the indentation or quote the value`},
+		{"- name: a\n  <<: x\n", "tasks.yml:2:3: << merge key needs a mapping or a list of mappings"},
+		{"- name: a\n  scheduel: b\n", `tasks.yml:2:3: unknown key "scheduel", did you mean "schedule"?`},
+	} {
+		_, err := parseTaskData([]byte(tc.data), "tasks.yml", FormatYAML)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: got error %v, want %s", tc.data, err, tc.want)
+		}
+	}
+}
+
+func TestParseYAMLEmpty(t *testing.T) {
+	for _, data := range []string{"", "# only comments\n\n", "---\n"} {
+		root, err := parseYAMLNodes([]byte(data))
+		if err != nil || root != nil {
+			t.Errorf("%q: got %+v, %v want an empty document", data, root, err)
+		}
+	}
+}